@@ -0,0 +1,75 @@
+package httpeeve
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// WithLearnedDelays makes the client adapt to the pacing its upstreams ask for. Whenever a Conditioner
+// suggests a delay for a host, for instance through ScaleByServerLoad, that delay is remembered and used
+// as the minimum wait between retries to the host, until it answers successfully again.
+func WithLearnedDelays() Option {
+	return func(c *BackoffClient) {
+		c.learnDelays = true
+	}
+}
+
+// adaptiveState is what the client has learned about its upstreams. It is encoded as JSON by Snapshot.
+type adaptiveState struct {
+	LearnedDelays map[string]time.Duration `json:"learned_delays"`
+}
+
+// learnedDelays keeps the delays suggested by each host.
+type learnedDelays struct {
+	mu     sync.Mutex
+	delays map[string]time.Duration
+}
+
+func newLearnedDelays() *learnedDelays {
+	return &learnedDelays{delays: map[string]time.Duration{}}
+}
+
+func (l *learnedDelays) get(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.delays[host]
+}
+
+func (l *learnedDelays) learn(host string, delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.delays[host] = delay
+}
+
+func (l *learnedDelays) forget(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.delays, host)
+}
+
+// Snapshot encodes the state adaptive behaviour like WithLearnedDelays has built up, so that it can survive
+// a restart of your service by passing it to Restore.
+func (c *BackoffClient) Snapshot() ([]byte, error) {
+	c.learned.mu.Lock()
+	defer c.learned.mu.Unlock()
+
+	return json.Marshal(adaptiveState{LearnedDelays: c.learned.delays})
+}
+
+// Restore replaces the adaptive state of the client with one taken by Snapshot.
+func (c *BackoffClient) Restore(snapshot []byte) error {
+	var state adaptiveState
+	if err := json.Unmarshal(snapshot, &state); err != nil {
+		return err
+	}
+
+	if state.LearnedDelays == nil {
+		state.LearnedDelays = map[string]time.Duration{}
+	}
+
+	c.learned.mu.Lock()
+	defer c.learned.mu.Unlock()
+	c.learned.delays = state.LearnedDelays
+	return nil
+}
@@ -0,0 +1,77 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func firstRetryDelay(client *BackoffClient) time.Duration {
+	for len(client.Events()) > 0 {
+		if event := <-client.Events(); event.Type == Retrying {
+			return event.Delay
+		}
+	}
+	return 0
+}
+
+func TestSnapshotAndRestoreLearnedDelays(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		switch requestCount {
+		case 1, 2:
+			w.Header().Set(ServerLoadHeader, "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	newClient := func() *BackoffClient {
+		return NewBackoffClient(http.Client{}, backoff.WithMaxRetries(backoff.NewConstantBackOff(2*time.Millisecond), 1),
+			ScaleByServerLoad(retryOn5XX, time.Second), WithLearnedDelays(), WithEvents(20))
+	}
+
+	// The first client learns the delay the fully loaded server asks for, then gives up.
+	learner := newClient()
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := learner.Do(req)
+	assert.EqualError(t, err, "bad status code 503")
+	assert.Equal(t, 10*time.Millisecond, firstRetryDelay(learner))
+
+	snapshot, err := learner.Snapshot()
+	assert.NoError(t, err)
+
+	restored := newClient()
+	assert.NoError(t, restored.Restore(snapshot))
+
+	// After a restart, the learned delay still applies to retries without a suggestion of their own.
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := restored.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Equal(t, 10*time.Millisecond, firstRetryDelay(restored))
+	assert.Equal(t, time.Duration(0), restored.learned.get(mustParseURL(server.URL).Host), "a success resets what was learned")
+}
+
+func TestRestoreRejectsInvalidSnapshots(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+	assert.Error(t, client.Restore([]byte("not json")))
+}
+
+func mustParseURL(raw string) *url.URL {
+	u, err := url.Parse(raw)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
@@ -0,0 +1,74 @@
+package httpeeve
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Advice is the decision of an Advisor on a response. A zero Advice accepts the response.
+type Advice struct {
+	// Retry asks for the request to be retried.
+	Retry bool
+	// After, if positive, is how long to wait before the next attempt instead of the interval of the backoff.
+	After time.Duration
+	// Reason explains why the response is not accepted. It becomes the message of the returned error.
+	Reason string
+	// Permanent marks the response as erroneous without retrying it. It takes precedence over Retry.
+	Permanent bool
+
+	// err is the error a Conditioner returned, which is kept so that nothing is lost in translation.
+	err error
+}
+
+// Advisor is a richer variant of a Conditioner that gives its decision, how long to wait and why in one call.
+type Advisor func(resp *http.Response) Advice
+
+// Conditioner adapts the Advisor, so that it can be used wherever a Conditioner is expected.
+func (a Advisor) Conditioner() Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		advice := a(resp)
+		switch {
+		case advice.Permanent:
+			return false, advice.error()
+		case advice.Retry && advice.After > 0:
+			return true, &delaySuggestion{err: advice.error(), adjust: waitFor(advice.After)}
+		case advice.Retry:
+			return true, advice.error()
+		default:
+			return OK()
+		}
+	}
+}
+
+// Advisor adapts the Conditioner to an Advisor.
+func (c Conditioner) Advisor() Advisor {
+	return c.advise
+}
+
+func (c Conditioner) advise(resp *http.Response) Advice {
+	shouldRetry, err := c(resp)
+	if err == nil || err == errAbstain {
+		return Advice{}
+	}
+
+	return Advice{Retry: shouldRetry, Permanent: !shouldRetry, Reason: err.Error(), err: err}
+}
+
+func (a Advice) error() error {
+	if a.err != nil {
+		return a.err
+	}
+	if a.Reason == "" {
+		return errors.New("response not accepted")
+	}
+	return errors.New(a.Reason)
+}
+
+// waitFor returns a delay adjustment that replaces the interval of the backoff with d.
+func waitFor(d time.Duration) func(time.Duration, time.Time) time.Duration {
+	return func(time.Duration, time.Time) time.Duration {
+		return d
+	}
+}
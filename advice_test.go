@@ -0,0 +1,75 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdvisorWithAfterAndReason(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	advisor := Advisor(func(resp *http.Response) Advice {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return Advice{Retry: true, After: 5 * time.Millisecond, Reason: "throttled"}
+		}
+		return Advice{}
+	})
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Hour), advisor.Conditioner(), WithEvents(10))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+
+	<-client.Events()
+	retrying := <-client.Events()
+	assert.Equal(t, Retrying, retrying.Type)
+	assert.Equal(t, 5*time.Millisecond, retrying.Delay)
+	assert.EqualError(t, retrying.Err, "throttled")
+}
+
+func TestAdvisorPermanentTakesPrecedence(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	advisor := Advisor(func(resp *http.Response) Advice {
+		return Advice{Retry: true, Permanent: true, Reason: "malformed request"}
+	})
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, advisor.Conditioner())
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.EqualError(t, err, "malformed request")
+	assert.Equal(t, 1, Attempts(resp))
+}
+
+func TestConditionerAdvisor(t *testing.T) {
+	advise := Conditioner(retryOn5XX).Advisor()
+
+	assert.Equal(t, Advice{}, advise(&http.Response{StatusCode: 200}))
+
+	advice := advise(&http.Response{StatusCode: 503})
+	assert.True(t, advice.Retry)
+	assert.False(t, advice.Permanent)
+	assert.Equal(t, "bad status code 503", advice.Reason)
+
+	advice = advise(&http.Response{StatusCode: 404})
+	assert.False(t, advice.Retry)
+	assert.True(t, advice.Permanent)
+}
@@ -0,0 +1,38 @@
+package httpeeve
+
+import (
+	"net/http"
+	"time"
+)
+
+type contextKeyAttempt struct{}
+
+// attemptInfo describes the attempt a response belongs to, for Conditioners that need more than the response.
+type attemptInfo struct {
+	number int
+	start  time.Time
+	// previousLatency is how long the previous attempt took to receive response headers.
+	previousLatency time.Duration
+	// request is the request passed to Do, which the request of the attempt is a copy of.
+	request *http.Request
+	// now tells the time with the clock of the client.
+	now func() time.Time
+}
+
+func attemptOf(resp *http.Response) attemptInfo {
+	info, _ := valueOf(resp, contextKeyAttempt{}).(attemptInfo)
+	if info.now == nil {
+		info.now = time.Now
+	}
+	return info
+}
+
+// OriginalRequest returns the request passed to Do that req is the copy of an attempt of, as passed to
+// Hooks.OnAttemptStart and Hooks.OnAttemptDone. It returns req itself for any other request. Unlike the copies,
+// the original request stays the same across attempts, which makes it fit to tell the attempts of requests apart.
+func OriginalRequest(req *http.Request) *http.Request {
+	if info, ok := req.Context().Value(contextKeyAttempt{}).(attemptInfo); ok && info.request != nil {
+		return info.request
+	}
+	return req
+}
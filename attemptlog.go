@@ -0,0 +1,74 @@
+package httpeeve
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+type contextKeyAttemptLog struct{}
+
+// AttemptLogEntry records why an attempt of a request was made and how it went.
+type AttemptLogEntry struct {
+	Attempt int
+	// Trigger is "initial" for the first attempt. For retries it names what went wrong with the previous
+	// attempt: "retry:" followed by its status code, "retry:timeout" or "retry:error".
+	Trigger string
+	// Wait is how long the client waited before the attempt.
+	Wait time.Duration
+	// Start is when the attempt was sent and Duration how long it took to receive the response headers, or
+	// to fail. Both are zero for attempts that were given up on before sending.
+	Start    time.Time
+	Duration time.Duration
+	// StatusCode is the status code of the response to the attempt, or 0 if it got none.
+	StatusCode int
+	// Err is why the attempt failed, as returned by the Conditioner or the transport, or nil if it succeeded.
+	Err error
+}
+
+// AttemptLog returns the log of all attempts made for the request that resulted in resp.
+func AttemptLog(resp *http.Response) []AttemptLogEntry {
+	log, _ := valueOf(resp, contextKeyAttemptLog{}).([]AttemptLogEntry)
+	return log
+}
+
+// AttemptHistory returns the log of all attempts made for a request that Do gave up on with err, for when there
+// is no response to pass to AttemptLog. It returns nothing unless err is or wraps a RetryError.
+func AttemptHistory(err error) []AttemptLogEntry {
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		return nil
+	}
+	return retryErr.History
+}
+
+// retryTrigger names what caused the attempt that resulted in resp and err to be retried.
+func retryTrigger(resp *http.Response, err error) string {
+	switch {
+	case resp != nil:
+		return "retry:" + strconv.Itoa(resp.StatusCode)
+	case isTimeout(err):
+		return "retry:timeout"
+	default:
+		return "retry:error"
+	}
+}
+
+// recordOutcome completes the log entry of the latest attempt with its response and err.
+func (c *call) recordOutcome(err error) {
+	if permanent, ok := err.(*backoff.PermanentError); ok {
+		err = permanent.Err
+	}
+	entry := &c.log[len(c.log)-1]
+	entry.StatusCode, entry.Err = statusCodeOf(c.resp), err
+}
+
+func addAttemptLogToRequest(resp *http.Response, log []AttemptLogEntry) {
+	if resp != nil && resp.Request != nil && resp.Request.Context() != nil {
+		resp.Request = resp.Request.WithContext(context.WithValue(resp.Request.Context(), contextKeyAttemptLog{}, log))
+	}
+}
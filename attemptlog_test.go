@@ -0,0 +1,69 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttemptLog(t *testing.T) {
+	var requestCount int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch atomic.AddInt64(&requestCount, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			time.Sleep(100 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{Timeout: 30 * time.Millisecond}, backoff.NewConstantBackOff(time.Millisecond), retryOn5XX)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+
+	log := AttemptLog(resp)
+	for i := range log {
+		assert.False(t, log[i].Start.IsZero())
+		assert.True(t, log[i].Duration > 0)
+	}
+	assert.EqualError(t, log[0].Err, "bad status code 503")
+	assert.True(t, isTimeout(log[1].Err))
+	assert.NoError(t, log[2].Err)
+	assert.Equal(t, []AttemptLogEntry{
+		{Attempt: 1, Trigger: "initial", StatusCode: 503},
+		{Attempt: 2, Trigger: "retry:503", Wait: time.Millisecond},
+		{Attempt: 3, Trigger: "retry:timeout", Wait: time.Millisecond, StatusCode: 200},
+	}, withoutErrors(withoutTimings(log)))
+}
+
+func TestAttemptHistoryWithoutResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1), retryOn5XX)
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, err := client.Do(req)
+	assert.Nil(t, resp)
+
+	history := AttemptHistory(err)
+	assert.Len(t, history, 2)
+	for i, entry := range history {
+		assert.Equal(t, i+1, entry.Attempt)
+		assert.Equal(t, 0, entry.StatusCode)
+		assert.Error(t, entry.Err)
+	}
+	assert.Equal(t, "retry:error", history[1].Trigger)
+	assert.Nil(t, AttemptHistory(nil))
+}
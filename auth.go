@@ -0,0 +1,75 @@
+package httpeeve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cenkalti/backoff"
+)
+
+// ErrAuthExpired is the error of AuthExpired, telling that the credentials of a request are no longer valid.
+var ErrAuthExpired = errors.New("authentication expired")
+
+// AuthExpired signals that the credentials of the request expired, see WithAuthRefresher. Without a refresher it
+// is an error that cannot be retried.
+func AuthExpired() (bool, error) {
+	return false, ErrAuthExpired
+}
+
+// AuthRefresher renews the credentials of req after resp told that they expired, for instance by fetching a
+// new bearer token and setting the Authorization header of req. The body of resp is still unread.
+type AuthRefresher func(ctx context.Context, req *http.Request, resp *http.Response) error
+
+// WithAuthRefresher lets the client renew expired credentials: when a response has the status code 401
+// Unauthorized, or the Conditioner signals AuthExpired, refresh is called on a copy of the request, and the
+// request is sent again right away with the headers of that copy, once per request, without waiting for the
+// backoff. The request passed to Do is left as it is. The retry counts towards the limits of the client and its
+// retry budget, and is reported to hooks, events and metrics like any other. If that attempt fails as well, the
+// Conditioner and the backoff take over as usual. An error of refresh is returned without retrying.
+func WithAuthRefresher(refresh AuthRefresher) Option {
+	return func(c *BackoffClient) {
+		c.authRefresher = refresh
+	}
+}
+
+// shouldRefreshAuth tells whether the latest attempt, which ended in err, ran into expired credentials that
+// were not renewed before.
+func (c *call) shouldRefreshAuth(err error) bool {
+	if c.client.authRefresher == nil || c.authRefreshed {
+		return false
+	}
+	if permanent, ok := err.(*backoff.PermanentError); ok {
+		err = permanent.Err
+	}
+	return c.resp != nil && c.resp.StatusCode == http.StatusUnauthorized || errors.Is(err, ErrAuthExpired)
+}
+
+// refreshAuthAndRetry renews the credentials of the call after the attempt that ended in err, and returns the
+// error to retry it with right away.
+func (c *call) refreshAuthAndRetry(err error) error {
+	c.authRefreshed = true
+	if permanent, ok := err.(*backoff.PermanentError); ok {
+		err = permanent.Err
+	}
+	if err == nil {
+		err = ErrAuthExpired
+	}
+	c.errors = append(c.errors, err)
+
+	refreshed := c.req.Clone(c.req.Context())
+	if err := c.client.authRefresher(c.req.Context(), refreshed, c.resp); err != nil {
+		c.permanent = true
+		return backoff.Permanent(fmt.Errorf("refreshing authentication: %w", err))
+	}
+	c.authHeader = refreshed.Header
+	if err := c.takeFingerprint(); err != nil {
+		c.permanent = true
+		return backoff.Permanent(err)
+	}
+
+	c.trigger, c.permanent = "auth-refresh", false
+	c.backoffer.immediate = true
+	return err
+}
@@ -0,0 +1,164 @@
+package httpeeve
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+// tokenServer accepts requests with the bearer token "fresh", and counts them by the token they carry.
+func tokenServer(tokens map[string]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token := req.Header.Get("Authorization")
+		tokens[token]++
+		if token != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+}
+
+func refreshTo(token string, refreshed *int) AuthRefresher {
+	return func(ctx context.Context, req *http.Request, resp *http.Response) error {
+		*refreshed++
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+func TestWithAuthRefresher(t *testing.T) {
+	tokens := map[string]int{}
+	server := tokenServer(tokens)
+	defer server.Close()
+
+	var delays []time.Duration
+	var refreshed int
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Second), retryOn5XX,
+		WithAuthRefresher(refreshTo("fresh", &refreshed)), WithSleepFunc(func(d time.Duration) { delays = append(delays, d) }))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Authorization", "Bearer stale")
+	resp, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Equal(t, 1, refreshed)
+	assert.Equal(t, []time.Duration{0}, delays, "the retry is immediate")
+	assert.Equal(t, map[string]int{"Bearer stale": 1, "Bearer fresh": 1}, tokens)
+	assert.Equal(t, "auth-refresh", AttemptLog(resp)[1].Trigger)
+	assert.Equal(t, "Bearer stale", req.Header.Get("Authorization"), "the request passed to Do is left as it is")
+}
+
+func TestWithAuthRefresherWithFingerprintCheck(t *testing.T) {
+	tokens := map[string]int{}
+	server := tokenServer(tokens)
+	defer server.Close()
+
+	var refreshed int
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX,
+		WithAuthRefresher(refreshTo("fresh", &refreshed)), WithFingerprintCheck())
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	req.Header.Set("Authorization", "Bearer stale")
+	resp, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Equal(t, map[string]int{"Bearer stale": 1, "Bearer fresh": 1}, tokens)
+	assert.Equal(t, "Bearer stale", req.Header.Get("Authorization"))
+}
+
+func TestWithAuthRefresherReportsRetry(t *testing.T) {
+	tokens := map[string]int{}
+	server := tokenServer(tokens)
+	defer server.Close()
+
+	var refreshed, retries int
+	var scheduled []time.Duration
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Second), retryOn5XX,
+		WithAuthRefresher(refreshTo("fresh", &refreshed)), WithSleepFunc(func(time.Duration) {}),
+		WithOnRetry(func(error, int, time.Duration) { retries++ }),
+		WithHooks(Hooks{OnRetryScheduled: func(req *http.Request, attempt int, delay time.Duration, err error) {
+			scheduled = append(scheduled, delay)
+		}}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Authorization", "Bearer stale")
+	_, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, retries)
+	assert.Equal(t, []time.Duration{0}, scheduled)
+}
+
+func TestWithAuthRefresherRefreshesOnce(t *testing.T) {
+	tokens := map[string]int{}
+	server := tokenServer(tokens)
+	defer server.Close()
+
+	var refreshed int
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithAuthRefresher(refreshTo("revoked", &refreshed)))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+
+	assert.EqualError(t, err, "bad status code 401")
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Equal(t, 1, refreshed)
+}
+
+func TestWithAuthRefresherError(t *testing.T) {
+	tokens := map[string]int{}
+	server := tokenServer(tokens)
+	defer server.Close()
+
+	failed := errors.New("token endpoint unavailable")
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX,
+		WithAuthRefresher(func(ctx context.Context, req *http.Request, resp *http.Response) error {
+			return failed
+		}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+
+	assert.True(t, errors.Is(err, failed))
+	assert.EqualError(t, err, "refreshing authentication: token endpoint unavailable")
+	assert.Equal(t, 1, Attempts(resp))
+}
+
+func TestAuthExpiredConditioner(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if req.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	forbiddenIsExpired := func(resp *http.Response) (bool, error) {
+		if resp.StatusCode == http.StatusForbidden {
+			return AuthExpired()
+		}
+		return retryOn5XX(resp)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, forbiddenIsExpired).Do(req)
+	assert.Equal(t, ErrAuthExpired, err, "expired credentials are not retried without a refresher")
+	assert.Equal(t, 1, requestCount)
+
+	var refreshed int
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, forbiddenIsExpired, WithAuthRefresher(refreshTo("fresh", &refreshed)))
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+}
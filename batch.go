@@ -0,0 +1,171 @@
+package httpeeve
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BatchOptions configures DoAll.
+type BatchOptions struct {
+	// Parallelism is the number of requests sent at the same time. With 0 or less, all of them are.
+	Parallelism int
+	// FailFast cancels the other requests as soon as one fails, and makes DoAll return its error.
+	FailFast bool
+}
+
+// BatchResult is the outcome of one of the requests of DoAll.
+type BatchResult struct {
+	// Response and Err are what Do returned for the request. Requests that were not sent because the batch was
+	// cancelled fail with the error of its context.
+	Response *http.Response
+	Err      error
+	// Result tells how many attempts the request took, and how long.
+	Result
+}
+
+// BatchResults are the outcomes of the requests of DoAll, in the order of the requests.
+type BatchResults []BatchResult
+
+// BatchStats sums up the outcomes of a batch, see BatchResults.Stats.
+type BatchStats struct {
+	Requests  int
+	Succeeded int
+	Failed    int
+	// Attempts is the number of attempts made for all requests together.
+	Attempts int
+	// Slowest is the index of the request that took longest, or -1 for an empty batch, and SlowestElapsed how
+	// long it took.
+	Slowest        int
+	SlowestElapsed time.Duration
+}
+
+// Stats sums up results.
+func (results BatchResults) Stats() BatchStats {
+	stats := BatchStats{Requests: len(results), Slowest: -1}
+	for i, result := range results {
+		if result.Err != nil {
+			stats.Failed++
+		} else {
+			stats.Succeeded++
+		}
+		stats.Attempts += result.Attempts
+		if stats.Slowest < 0 || result.Elapsed > stats.SlowestElapsed {
+			stats.Slowest, stats.SlowestElapsed = i, result.Elapsed
+		}
+	}
+	return stats
+}
+
+// BatchError is returned by DoAll when requests of a batch without FailFast failed. It unwraps to the error of
+// the first of them, in the order of the requests.
+type BatchError struct {
+	// Failed is the number of requests that failed, out of Requests.
+	Failed   int
+	Requests int
+	// First is the index of the first request that failed, and Err its error.
+	First int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d of %d requests failed, request %d: %v", e.Failed, e.Requests, e.First, e.Err)
+}
+
+// Unwrap returns the error of the first request that failed.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// DoAll sends reqs with Do, opts.Parallelism at a time, and returns their outcomes in the order of reqs. Every
+// request is retried on its own, according to the policy it selects, see TagRequest and WithPolicyFor.
+// Cancelling ctx cancels the requests in flight, and fails the others without sending them.
+//
+// Without opts.FailFast every request is sent, and the error is a *BatchError if any of them failed. With it,
+// the first failure cancels the other requests and is returned, wrapped with the index of its request. Either
+// way, the caller needs to close the bodies of all responses.
+func (c *BackoffClient) DoAll(ctx context.Context, reqs []*http.Request, opts BatchOptions) (BatchResults, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	parallelism := opts.Parallelism
+	if parallelism <= 0 || parallelism > len(reqs) {
+		parallelism = len(reqs)
+	}
+
+	results := make(BatchResults, len(reqs))
+	var (
+		next      int64
+		wg        sync.WaitGroup
+		firstOnce sync.Once
+		firstErr  error
+	)
+	for worker := 0; worker < parallelism; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1) - 1)
+				if i >= len(reqs) {
+					return
+				}
+				if results[i].Err = ctx.Err(); results[i].Err == nil {
+					results[i].Response, results[i].Err = c.doInBatch(ctx, reqs[i], &results[i].Result)
+				}
+				if results[i].Err != nil && opts.FailFast {
+					err := results[i].Err
+					firstOnce.Do(func() {
+						firstErr = fmt.Errorf("request %d: %w", i, err)
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if opts.FailFast {
+		return results, firstErr
+	}
+
+	batchErr := &BatchError{Requests: len(reqs), First: -1}
+	for i, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		batchErr.Failed++
+		if batchErr.First < 0 {
+			batchErr.First, batchErr.Err = i, result.Err
+		}
+	}
+	if batchErr.Failed == 0 {
+		return results, nil
+	}
+	return results, batchErr
+}
+
+// doInBatch sends req with Do, cancelling it when batch is done, and records how it went in result.
+func (c *BackoffClient) doInBatch(batch context.Context, req *http.Request, result *Result) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(RecordResult(req.Context(), result))
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-batch.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+
+	resp, err := c.Do(req.WithContext(ctx))
+	close(stop)
+	if resp == nil || resp.Body == nil {
+		cancel()
+	} else {
+		// the body is still to be read after the batch is done
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	}
+	return resp, err
+}
@@ -0,0 +1,115 @@
+package httpeeve
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoAll(t *testing.T) {
+	var inFlight, maxInFlight, flaky int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		current := atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		for {
+			max := atomic.LoadInt64(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt64(&maxInFlight, max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		switch req.URL.Path {
+		case "/flaky":
+			if atomic.AddInt64(&flaky, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	var reqs []*http.Request
+	for _, path := range []string{"/a", "/flaky", "/missing", "/b", "/c", "/d"} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		reqs = append(reqs, req)
+	}
+
+	results, err := client.DoAll(context.Background(), reqs, BatchOptions{Parallelism: 2})
+	for _, result := range results {
+		if result.Response != nil {
+			result.Response.Body.Close()
+		}
+	}
+
+	var batchErr *BatchError
+	if assert.True(t, errors.As(err, &batchErr)) {
+		assert.Equal(t, 1, batchErr.Failed)
+		assert.Equal(t, 2, batchErr.First)
+		assert.EqualError(t, err, "1 of 6 requests failed, request 2: bad status code 404")
+	}
+	assert.Equal(t, int64(2), atomic.LoadInt64(&maxInFlight))
+	assert.Len(t, results, 6)
+	assert.Equal(t, 2, results[1].Attempts)
+	assert.Equal(t, reqs[3].URL.Path, results[3].Response.Request.URL.Path, "results are in the order of the requests")
+
+	stats := results.Stats()
+	assert.Equal(t, 6, stats.Requests)
+	assert.Equal(t, 5, stats.Succeeded)
+	assert.Equal(t, 1, stats.Failed)
+	assert.Equal(t, 7, stats.Attempts)
+	assert.Equal(t, 1, stats.Slowest)
+	assert.True(t, stats.SlowestElapsed >= 20*time.Millisecond)
+}
+
+func TestDoAllFailFast(t *testing.T) {
+	var requestCount int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		if req.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	var reqs []*http.Request
+	for _, path := range []string{"/a", "/missing", "/b", "/c"} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		reqs = append(reqs, req)
+	}
+
+	results, err := client.DoAll(context.Background(), reqs, BatchOptions{Parallelism: 1, FailFast: true})
+	assert.EqualError(t, err, "request 1: bad status code 404")
+	assert.Equal(t, int64(2), atomic.LoadInt64(&requestCount))
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, context.Canceled, results[2].Err)
+	assert.Equal(t, context.Canceled, results[3].Err)
+	results[0].Response.Body.Close()
+}
+
+func TestDoAllCancelled(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := client.DoAll(ctx, []*http.Request{req}, BatchOptions{})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 0, results[0].Attempts)
+
+	results, err = client.DoAll(context.Background(), nil, BatchOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, BatchStats{Slowest: -1}, results.Stats())
+}
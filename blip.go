@@ -0,0 +1,43 @@
+package httpeeve
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// ImmediateFirstRetry wraps b so that the first retry happens right away, to get over transient blips,
+// and later ones follow the intervals of b.
+func ImmediateFirstRetry(b backoff.BackOff) backoff.BackOff {
+	return &immediateFirstRetry{BackOff: b}
+}
+
+type immediateFirstRetry struct {
+	backoff.BackOff
+	retried bool
+}
+
+func (b *immediateFirstRetry) NextBackOff() time.Duration {
+	if !b.retried {
+		b.retried = true
+		return 0
+	}
+	return b.BackOff.NextBackOff()
+}
+
+func (b *immediateFirstRetry) Reset() {
+	b.retried = false
+	b.BackOff.Reset()
+}
+
+// NewBlipTolerantClient returns a client for the common case: one immediate retry for transient blips,
+// then an exponential backoff starting at 100ms, growing to at most 10s between attempts, for up to a minute.
+func NewBlipTolerantClient(httpClient http.Client, conditioner Conditioner, opts ...Option) *BackoffClient {
+	exponential := backoff.NewExponentialBackOff()
+	exponential.InitialInterval = 100 * time.Millisecond
+	exponential.MaxInterval = 10 * time.Second
+	exponential.MaxElapsedTime = time.Minute
+
+	return NewBackoffClient(httpClient, ImmediateFirstRetry(exponential), conditioner, opts...)
+}
@@ -0,0 +1,52 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImmediateFirstRetry(t *testing.T) {
+	b := ImmediateFirstRetry(backoff.NewConstantBackOff(time.Second))
+	assert.Equal(t, time.Duration(0), b.NextBackOff())
+	assert.Equal(t, time.Second, b.NextBackOff())
+	assert.Equal(t, time.Second, b.NextBackOff())
+
+	b.Reset()
+	assert.Equal(t, time.Duration(0), b.NextBackOff())
+}
+
+func TestBlipTolerantClient(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < 4 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBlipTolerantClient(http.Client{}, retryOn5XX, WithEvents(20))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, Attempts(resp))
+
+	var delays []time.Duration
+	for len(client.Events()) > 0 {
+		if event := <-client.Events(); event.Type == Retrying {
+			delays = append(delays, event.Delay)
+		}
+	}
+	assert.Len(t, delays, 3)
+	assert.Equal(t, time.Duration(0), delays[0], "the first retry is immediate")
+	assert.True(t, delays[1] >= 50*time.Millisecond && delays[1] <= 150*time.Millisecond, delays[1].String())
+	assert.True(t, delays[2] >= 75*time.Millisecond && delays[2] <= 225*time.Millisecond, delays[2].String())
+}
@@ -0,0 +1,81 @@
+package httpeeve
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// peekBody reads the body of resp and puts an unread copy back in its place, so that Conditioners can
+// inspect it without taking it away from the caller.
+func peekBody(resp *http.Response) ([]byte, error) {
+	if resp.Body == nil {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+// peekBodyUpTo is like peekBody, but reads at most maxBytes of the body. It tells whether the body was longer,
+// in which case the unread rest of it is put back as well.
+func peekBodyUpTo(resp *http.Response, maxBytes int64) ([]byte, bool, error) {
+	if resp.Body == nil {
+		return nil, false, nil
+	}
+
+	original := resp.Body
+	body, err := ioutil.ReadAll(io.LimitReader(original, maxBytes+1))
+	if int64(len(body)) <= maxBytes {
+		original.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return body, false, err
+	}
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), original), original}
+	return body[:maxBytes], true, err
+}
+
+// WithRetryOnBodyReadError makes the client read the whole body of responses to idempotent requests before
+// judging them, and retry those whose body fails to arrive in full, for instance because the connection
+// broke midway. Such failures happen after the response headers, so they are not transport errors. The body
+// is buffered and restored, so Conditioners and callers can still read it.
+func WithRetryOnBodyReadError(retry bool) Option {
+	return func(c *BackoffClient) {
+		c.retryOnBodyReadError = retry
+	}
+}
+
+// WithResponseBuffering lets Conditioners read response bodies freely, for instance to retry a 200 carrying
+// {"status":"RETRY_LATER"}: the client reads the first maxBytes of every body and hands the Conditioner a copy of
+// them, then puts the whole body back in place for the caller, however much of it the Conditioner consumed.
+// Conditioners only see the beginning of longer bodies, which are not buffered beyond maxBytes.
+func WithResponseBuffering(maxBytes int64) Option {
+	return func(c *BackoffClient) {
+		c.maxBufferedResponse = maxBytes
+	}
+}
+
+// lendBody replaces the body of resp with a copy of its first maxBytes, and returns a function to put the whole
+// body back in place.
+func lendBody(resp *http.Response, maxBytes int64) (func(), error) {
+	if resp == nil || resp.Body == nil {
+		return func() {}, nil
+	}
+
+	head, _, err := peekBodyUpTo(resp, maxBytes)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	whole := resp.Body
+	resp.Body = ioutil.NopCloser(bytes.NewReader(head))
+	return func() { resp.Body = whole }, nil
+}
@@ -0,0 +1,96 @@
+package httpeeve
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryOnBodyReadError(t *testing.T) {
+	var requestCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.Header().Set("Content-Length", "8")
+		if requestCount == 1 {
+			w.Write([]byte("part"))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		w.Write([]byte("complete"))
+	}))
+	defer ts.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithRetryOnBodyReadError(true))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "complete", string(body))
+}
+
+func TestBodyReadErrorIsLeftToCallerByDefault(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "8")
+		w.Write([]byte("part"))
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}))
+	defer ts.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, Attempts(resp))
+
+	_, err = ioutil.ReadAll(resp.Body)
+	assert.Error(t, err)
+}
+
+func TestResponseBuffering(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.Write([]byte(`{"status":"RETRY_LATER"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"ok","padding":"0123456789"}`))
+	}))
+	defer server.Close()
+
+	var seen []string
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, func(resp *http.Response) (bool, error) {
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return PermanentErrorf("reading body: %s", err)
+		}
+		seen = append(seen, string(body))
+		if bytes.Contains(body, []byte("RETRY_LATER")) {
+			return RetriableError("retry later")
+		}
+		return OK()
+	}, WithResponseBuffering(24))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Equal(t, []string{`{"status":"RETRY_LATER"}`, `{"status":"ok","padding"`}, seen)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"status":"ok","padding":"0123456789"}`, string(body), "the caller gets the whole body")
+	resp.Body.Close()
+}
@@ -0,0 +1,117 @@
+package httpeeve
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, wrapped with the host, for requests that fail fast because the circuit breaker of
+// their host is open, see WithCircuitBreaker. Check for it with errors.Is.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// WithCircuitBreaker keeps the client from hammering hosts that are down. Once failureThreshold attempts in a
+// row to a host have failed with a retriable error or without a response, its circuit opens and every attempt
+// to it fails right away with ErrCircuitOpen, without being retried. After openDuration the circuit is
+// half-open and lets halfOpenProbes attempts through: if all of them succeed the circuit closes again, if one
+// fails it opens for another openDuration. Responses that are rejected permanently, such as a 404, do not
+// count as failures.
+func WithCircuitBreaker(failureThreshold int, openDuration time.Duration, halfOpenProbes int) Option {
+	if halfOpenProbes < 1 {
+		halfOpenProbes = 1
+	}
+	return func(c *BackoffClient) {
+		c.breaker = &circuitBreaker{
+			threshold: failureThreshold,
+			openFor:   openDuration,
+			probes:    halfOpenProbes,
+			now:       func() time.Time { return c.now() },
+			circuits:  map[string]*circuit{},
+		}
+	}
+}
+
+type circuitBreaker struct {
+	threshold int
+	openFor   time.Duration
+	probes    int
+	now       func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// circuit is the state of the breaker of a single host.
+type circuit struct {
+	failures int
+	open     bool
+	openedAt time.Time
+	// probing and succeeded count the attempts let through and succeeded while half-open.
+	probing   int
+	succeeded int
+}
+
+// attemptOutcome is what an attempt let through by the breaker tells about the health of its host.
+type attemptOutcome int
+
+const (
+	attemptSucceeded attemptOutcome = iota
+	attemptFailed
+	// attemptNotSent frees the slot of an attempt that was let through but never sent.
+	attemptNotSent
+)
+
+// allow returns nil if an attempt may be sent to host, or the error to fail it with.
+func (b *circuitBreaker) allow(host string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.circuits[host]
+	if state == nil || !state.open {
+		return nil
+	}
+	if b.now().Sub(state.openedAt) < b.openFor || state.probing >= b.probes {
+		return fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+	}
+
+	state.probing++
+	return nil
+}
+
+// record updates the circuit of host with the outcome of an attempt that allow let through.
+func (b *circuitBreaker) record(host string, outcome attemptOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.circuits[host]
+	if state == nil {
+		state = &circuit{}
+		b.circuits[host] = state
+	}
+
+	if state.open {
+		switch outcome {
+		case attemptNotSent:
+			state.probing--
+		case attemptFailed:
+			state.openedAt, state.probing, state.succeeded = b.now(), 0, 0
+		case attemptSucceeded:
+			state.succeeded++
+			if state.succeeded >= b.probes {
+				*state = circuit{}
+			}
+		}
+		return
+	}
+
+	switch outcome {
+	case attemptFailed:
+		state.failures++
+		if state.failures >= b.threshold {
+			*state = circuit{open: true, openedAt: b.now()}
+		}
+	case attemptSucceeded:
+		state.failures = 0
+	}
+}
@@ -0,0 +1,91 @@
+package httpeeve
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var requestCount, status int64 = 0, http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		w.WriteHeader(int(atomic.LoadInt64(&status)))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 5), retryOn5XX,
+		WithCircuitBreaker(2, time.Minute, 1), WithNow(func() time.Time { return now }))
+
+	do := func() (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		return client.Do(req)
+	}
+
+	_, err := do()
+	assert.True(t, errors.Is(err, ErrCircuitOpen), err)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&requestCount), "the circuit opens after 2 failures")
+
+	resp, err := do()
+	assert.True(t, errors.Is(err, ErrCircuitOpen), err)
+	assert.Nil(t, resp)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&requestCount), "an open circuit fails fast")
+
+	now = now.Add(time.Minute)
+	_, err = do()
+	assert.True(t, errors.Is(err, ErrCircuitOpen), err)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&requestCount), "a failed probe opens the circuit again")
+
+	now = now.Add(time.Minute)
+	atomic.StoreInt64(&status, http.StatusOK)
+	resp, err = do()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, Attempts(resp))
+
+	atomic.StoreInt64(&status, http.StatusServiceUnavailable)
+	_, err = do()
+	assert.True(t, errors.Is(err, ErrCircuitOpen), err)
+	assert.Equal(t, int64(6), atomic.LoadInt64(&requestCount), "a successful probe closes the circuit")
+}
+
+func TestCircuitBreakerIgnoresPermanentErrors(t *testing.T) {
+	var requestCount int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithCircuitBreaker(1, time.Minute, 1))
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		_, err := client.Do(req)
+		assert.False(t, errors.Is(err, ErrCircuitOpen), err)
+	}
+	assert.Equal(t, int64(3), atomic.LoadInt64(&requestCount))
+}
+
+func TestCircuitBreakerOpensOnPermanentErrorsWithoutResponse(t *testing.T) {
+	var dials int64
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt64(&dials, 1)
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: req.URL.Hostname(), IsNotFound: true}}
+	})
+	client := NewBackoffClient(http.Client{Transport: transport}, &backoff.ZeroBackOff{}, retryOn5XX, WithCircuitBreaker(2, time.Minute, 1))
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://nowhere.invalid", nil)
+		_, err := client.Do(req)
+		assert.Equal(t, i == 2, errors.Is(err, ErrCircuitOpen), err)
+	}
+	assert.Equal(t, int64(2), atomic.LoadInt64(&dials))
+}
@@ -0,0 +1,82 @@
+package httpeeve
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// ErrRetryBudgetExhausted is returned, wrapped with the error of the last attempt, for requests that would have
+// been retried but for the retry budget of the client, see WithRetryBudget. Check for it with errors.Is.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// WithRetryBudget keeps retries from amplifying an outage by limiting them to a ratio of the requests that
+// recently went well, shared by all requests of the client. Every request whose first attempt succeeds adds
+// ratio to the budget, such as 0.1 to allow a retry for every ten requests, and every retry takes one off. The
+// budget starts at and never exceeds burst, so that a client can retry before it has seen any traffic, and
+// requests cannot hoard retries during quiet times. A request that would be retried without any budget left
+// fails right away with ErrRetryBudgetExhausted.
+func WithRetryBudget(ratio float64, burst int) Option {
+	return func(c *BackoffClient) {
+		c.budget = &retryBudget{ratio: ratio, max: float64(burst), balance: float64(burst)}
+	}
+}
+
+type retryBudget struct {
+	ratio float64
+	max   float64
+
+	mu      sync.Mutex
+	balance float64
+}
+
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.balance += b.ratio
+	if b.balance > b.max {
+		b.balance = b.max
+	}
+}
+
+// withdraw takes a retry off the budget, and returns false if there is none left.
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.balance < 1 {
+		return false
+	}
+	b.balance--
+	return true
+}
+
+// budgetBackOff takes a retry off budget for every delay its backoff allows, and stops once the budget is
+// exhausted, so that attempts that are not going to be retried anyway leave the budget alone.
+type budgetBackOff struct {
+	backoff.BackOff
+	budget    *retryBudget
+	exhausted bool
+}
+
+func (b *budgetBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next == backoff.Stop || b.budget == nil {
+		return next
+	}
+
+	if !b.budget.withdraw() {
+		b.exhausted = true
+		return backoff.Stop
+	}
+	return next
+}
+
+// budgetExhausted returns the error for a request that could not be retried with err because the budget ran out.
+func budgetExhausted(err error) error {
+	return fmt.Errorf("%w: %v", ErrRetryBudgetExhausted, err)
+}
@@ -0,0 +1,77 @@
+package httpeeve
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryBudget(t *testing.T) {
+	var failing int64 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt64(&failing) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 5), retryOn5XX, WithRetryBudget(0.5, 2))
+	do := func() (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		return client.Do(req)
+	}
+
+	resp, err := do()
+	assert.True(t, errors.Is(err, ErrRetryBudgetExhausted), err)
+	assert.EqualError(t, err, "retry budget exhausted: bad status code 503")
+	assert.Equal(t, 3, Attempts(resp), "the burst allows 2 retries")
+
+	resp, err = do()
+	assert.True(t, errors.Is(err, ErrRetryBudgetExhausted), err)
+	assert.Equal(t, 1, Attempts(resp), "the budget is shared between requests")
+
+	atomic.StoreInt64(&failing, 0)
+	for i := 0; i < 2; i++ {
+		_, err = do()
+		assert.NoError(t, err)
+	}
+
+	atomic.StoreInt64(&failing, 1)
+	resp, err = do()
+	assert.True(t, errors.Is(err, ErrRetryBudgetExhausted), err)
+	assert.Equal(t, 2, Attempts(resp), "two successful requests earned a retry")
+}
+
+func TestRetryBudgetIsNotSpentOnPermanentErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithRetryBudget(0, 1))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.False(t, errors.Is(err, ErrRetryBudgetExhausted))
+	assert.True(t, client.budget.withdraw())
+}
+
+func TestRetryBudgetIsNotSpentOnRequestsThatAreNotRetried(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithMaxRetries(0), WithRetryBudget(0, 1))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.True(t, errors.Is(err, ErrMaxAttemptsExceeded), err)
+	assert.False(t, errors.Is(err, ErrRetryBudgetExhausted))
+	assert.Equal(t, float64(1), client.budget.balance)
+}
@@ -0,0 +1,68 @@
+package httpeeve
+
+import (
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+)
+
+// Category is the verdict of an ErrorClassifier on the error of a request that got no response.
+type Category int
+
+const (
+	// CategoryUnknown leaves the decision to the next classifier, and eventually to DefaultErrorClassifier.
+	CategoryUnknown Category = iota
+	// CategoryRetriable retries the request.
+	CategoryRetriable
+	// CategoryPermanent gives up on the request.
+	CategoryPermanent
+)
+
+// ErrorClassifier decides whether the error of a request that got no response, as returned by the transport,
+// is worth retrying.
+type ErrorClassifier func(err error) Category
+
+// WithErrorClassifier makes the client consult classifiers, in order, about the errors of requests that got no
+// response. The first one that does not answer CategoryUnknown decides, ahead of WithRetriableSyscallErrors,
+// WithRetryOnAttemptTimeout and DefaultErrorClassifier, which decide if none of them does.
+func WithErrorClassifier(classifiers ...ErrorClassifier) Option {
+	return func(c *BackoffClient) {
+		c.classifiers = append(c.classifiers, classifiers...)
+	}
+}
+
+// DefaultErrorClassifier is how clients classify errors that no other classifier recognizes. Connections that were
+// refused, or closed or broken by the server, including HTTP/2 servers going away, are retried, and so are HTTP/2
+// streams reset with REFUSED_STREAM or ENHANCE_YOUR_CALM, timeouts, such as TLS handshake timeouts, and temporary
+// DNS failures. Certificates that fail to verify, hosts that do not exist and anything else are permanent.
+func DefaultErrorClassifier(err error) Category {
+	var (
+		dnsErr       *net.DNSError
+		authorityErr x509.UnknownAuthorityError
+		invalidErr   x509.CertificateInvalidError
+		hostnameErr  x509.HostnameError
+	)
+	switch {
+	case errors.As(err, &authorityErr), errors.As(err, &invalidErr), errors.As(err, &hostnameErr):
+		return CategoryPermanent
+	case errors.As(err, &dnsErr):
+		if dnsErr.IsTimeout || dnsErr.IsTemporary {
+			return CategoryRetriable
+		}
+		return CategoryPermanent
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return CategoryRetriable
+	case isSyscallError(err, transientErrnos):
+		return CategoryRetriable
+	case isRetriableHTTP2Error(err):
+		return CategoryRetriable
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return CategoryRetriable
+	}
+
+	return CategoryPermanent
+}
@@ -0,0 +1,72 @@
+package httpeeve
+
+import (
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+// timeoutError is what "net/http" returns for TLS handshake timeouts.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "net/http: TLS handshake timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestDefaultErrorClassifier(t *testing.T) {
+	urlError := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://localhost", Err: err}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		err      error
+		category Category
+	}{
+		{"TLS handshake timeout", urlError(timeoutError{}), CategoryRetriable},
+		{"unknown authority", urlError(x509.UnknownAuthorityError{}), CategoryPermanent},
+		{"hostname mismatch", urlError(x509.HostnameError{Host: "localhost"}), CategoryPermanent},
+		{"expired certificate", urlError(x509.CertificateInvalidError{Reason: x509.Expired}), CategoryPermanent},
+		{"DNS timeout", urlError(&net.DNSError{Err: "i/o timeout", IsTimeout: true}), CategoryRetriable},
+		{"no such host", urlError(&net.DNSError{Err: "no such host", IsNotFound: true}), CategoryPermanent},
+		{"unknown", errors.New("something else"), CategoryPermanent},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.category, DefaultErrorClassifier(tc.err))
+		})
+	}
+}
+
+func TestWithErrorClassifier(t *testing.T) {
+	notFound := &url.Error{Op: "Get", URL: "http://localhost", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}
+	reset := &url.Error{Op: "Get", URL: "http://localhost", Err: &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}}
+
+	retryDNS := func(err error) Category {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			return CategoryRetriable
+		}
+		return CategoryUnknown
+	}
+	neverReset := func(err error) Category {
+		if errors.Is(err, syscall.ECONNRESET) {
+			return CategoryPermanent
+		}
+		return CategoryUnknown
+	}
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithErrorClassifier(retryDNS, neverReset))
+	assert.Equal(t, notFound, client.categorizeRequestError(testRequest, notFound))
+	assert.IsType(t, &backoff.PermanentError{}, client.categorizeRequestError(testRequest, reset))
+
+	unknown := errors.New("something else")
+	assert.IsType(t, &backoff.PermanentError{}, client.categorizeRequestError(testRequest, unknown), "the default decides")
+}
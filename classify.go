@@ -0,0 +1,138 @@
+package httpeeve
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// WithRetriableSyscallErrors declares low-level errors as retriable for your environment, for instance
+// syscall.EHOSTUNREACH while the network of an upstream is being reconfigured. A failed request is matched against them
+// by unwrapping its error to an *os.SyscallError. The POSIX errnos of the syscall package can be used on
+// every platform: on Windows they also match the corresponding Winsock errors. Refused, reset and broken
+// connections are retried without declaring them.
+func WithRetriableSyscallErrors(errnos ...syscall.Errno) Option {
+	return func(c *BackoffClient) {
+		for _, errno := range errnos {
+			c.retriableErrnos = append(c.retriableErrnos, errnoAliases(errno)...)
+		}
+	}
+}
+
+// WithRetryOnAttemptTimeout decides whether an attempt that ran into its own timeout, such as the Timeout of
+// the "net/http".Client, is retried. It is by default. Requests whose own context is done are not affected.
+func WithRetryOnAttemptTimeout(retry bool) Option {
+	return func(c *BackoffClient) {
+		c.retryOnAttemptTimeout = retry
+	}
+}
+
+// WithAttemptTimeout caps every attempt at timeout, including reading its response body, while the request as a
+// whole may take as long as its context and the backoff allow. An attempt that runs into the timeout is
+// cancelled and, unless disabled with WithRetryOnAttemptTimeout, retried.
+func WithAttemptTimeout(timeout time.Duration) Option {
+	return func(c *BackoffClient) {
+		c.attemptTimeout = timeout
+	}
+}
+
+// WithOverallTimeout caps every request at timeout, including all of its attempts, the waits between them and
+// reading the response body, like a deadline of the request context would. The client does not start a retry
+// that would be due after it, and fails the request with a DeadlineError as soon as it is reached. Combine it
+// with WithAttemptTimeout to also bound the attempts on their own.
+func WithOverallTimeout(timeout time.Duration) Option {
+	return func(c *BackoffClient) {
+		c.overallTimeout = timeout
+	}
+}
+
+// attemptContext derives the context of an attempt from ctx, applying the attempt timeout if there is one.
+func (c *BackoffClient) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.attemptTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.attemptTimeout)
+}
+
+func (c *BackoffClient) categorizeRequestError(req *http.Request, reqErr error) error {
+	for _, classify := range c.classifiers {
+		switch classify(reqErr) {
+		case CategoryRetriable:
+			return reqErr
+		case CategoryPermanent:
+			return backoff.Permanent(reqErr)
+		}
+	}
+
+	if isSyscallError(reqErr, c.retriableErrnos) {
+		return reqErr
+	}
+
+	if isTimeout(reqErr) && req.Context().Err() == nil {
+		if c.retryOnAttemptTimeout {
+			return reqErr
+		}
+		return backoff.Permanent(reqErr)
+	}
+
+	return categorizeRequestError(reqErr)
+}
+
+// transientErrnos are the low-level errors that are always retried: the connection was refused before the
+// request was sent, or it broke down while the request or the response were in flight.
+var transientErrnos = func() (errnos []syscall.Errno) {
+	for _, errno := range []syscall.Errno{syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.EPIPE} {
+		errnos = append(errnos, errnoAliases(errno)...)
+	}
+	return errnos
+}()
+
+// categorizeRequestError applies DefaultErrorClassifier to the error of a request that got no response.
+func categorizeRequestError(reqErr error) error {
+	if DefaultErrorClassifier(reqErr) == CategoryRetriable {
+		return reqErr
+	}
+	return backoff.Permanent(reqErr)
+}
+
+// isGoAway tells whether err is an HTTP/2 server shutting down the connection. The error type is internal to
+// "net/http", so its message is the only thing to go by.
+func isGoAway(err error) bool {
+	return strings.Contains(err.Error(), "http2: server sent GOAWAY")
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func isSyscallError(err error, errnos []syscall.Errno) bool {
+	var syscallErr *os.SyscallError
+	if !errors.As(err, &syscallErr) {
+		return false
+	}
+
+	errno, ok := syscallErr.Err.(syscall.Errno)
+	if !ok {
+		return false
+	}
+
+	for _, candidate := range errnos {
+		if errno == candidate {
+			return true
+		}
+	}
+
+	return false
+}
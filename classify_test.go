@@ -0,0 +1,207 @@
+package httpeeve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+var testRequest, _ = http.NewRequest(http.MethodGet, "http://localhost", nil)
+
+func TestCategorizeRequestError(t *testing.T) {
+	urlError := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://localhost", Err: err}
+	}
+	opError := func(op string, errno syscall.Errno) error {
+		return &net.OpError{Op: op, Net: "tcp", Err: os.NewSyscallError(op, errno)}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		err       error
+		retriable bool
+	}{
+		{"EOF", urlError(io.EOF), true},
+		{"wrapped unexpected EOF", urlError(fmt.Errorf("net/http: HTTP/1.x transport connection broken: %w", io.ErrUnexpectedEOF)), true},
+		{"connection refused", urlError(opError("dial", syscall.ECONNREFUSED)), true},
+		{"connection reset", urlError(opError("read", syscall.ECONNRESET)), true},
+		{"broken pipe", urlError(opError("write", syscall.EPIPE)), true},
+		{"GOAWAY", urlError(errors.New(`http2: server sent GOAWAY and closed the connection; LastStreamID=1, ErrCode=NO_ERROR, debug=""`)), true},
+		{"refused stream", urlError(errors.New("stream error: stream ID 3; REFUSED_STREAM; received from peer")), true},
+		{"enhance your calm", urlError(errors.New(`http2: server sent GOAWAY and closed the connection; LastStreamID=1, ErrCode=ENHANCE_YOUR_CALM, debug=""`)), true},
+		{"stream protocol error", urlError(errors.New("stream error: stream ID 5; PROTOCOL_ERROR")), false},
+		{"timeout", urlError(&net.DNSError{Err: "i/o timeout", Name: "localhost", IsTimeout: true}), true},
+		{"temporary", urlError(&net.DNSError{Err: "server misbehaving", Name: "localhost", IsTemporary: true}), true},
+		{"host unreachable", urlError(opError("dial", syscall.EHOSTUNREACH)), false},
+		{"unknown host", urlError(&net.DNSError{Err: "no such host", Name: "localhost", IsNotFound: true}), false},
+		{"unsupported scheme", urlError(errors.New(`unsupported protocol scheme "ftp"`)), false},
+		{"message mentioning EOF", urlError(errors.New("malformed header: EOF marker missing")), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := categorizeRequestError(tc.err)
+			if tc.retriable {
+				assert.Equal(t, tc.err, err)
+			} else {
+				assert.IsType(t, &backoff.PermanentError{}, err)
+			}
+		})
+	}
+}
+
+func TestRetriableSyscallErrors(t *testing.T) {
+	unreachable := &url.Error{Op: "Get", URL: "http://localhost", Err: &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.EHOSTUNREACH)}}
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+	assert.IsType(t, &backoff.PermanentError{}, client.categorizeRequestError(testRequest, unreachable))
+
+	client = NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithRetriableSyscallErrors(syscall.EHOSTUNREACH))
+	assert.Equal(t, unreachable, client.categorizeRequestError(testRequest, unreachable))
+}
+
+func TestRetriableSyscallErrorIsRetried(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var dials int
+	var dialer net.Dialer
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dials++
+			if dials == 1 {
+				return nil, &net.OpError{Op: "dial", Net: network, Err: os.NewSyscallError("connect", syscall.EHOSTUNREACH)}
+			}
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+
+	client := NewBackoffClient(http.Client{Transport: transport}, &backoff.ZeroBackOff{}, retryOn5XX, WithRetriableSyscallErrors(syscall.EHOSTUNREACH))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+}
+
+func TestRetryOnAttemptTimeout(t *testing.T) {
+	for _, retry := range []bool{true, false} {
+		var requestCount int64
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if atomic.AddInt64(&requestCount, 1) == 1 {
+				time.Sleep(100 * time.Millisecond)
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		client := NewBackoffClient(http.Client{Timeout: 20 * time.Millisecond}, &backoff.ZeroBackOff{}, retryOn5XX, WithRetryOnAttemptTimeout(retry))
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if retry {
+			assert.NoError(t, err)
+			assert.Equal(t, 2, Attempts(resp))
+		} else {
+			assert.Error(t, err)
+			assert.True(t, isTimeout(err))
+			assert.Equal(t, int64(1), atomic.LoadInt64(&requestCount))
+		}
+
+		server.Close()
+	}
+}
+
+func TestAttemptTimeout(t *testing.T) {
+	var requestCount int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt64(&requestCount, 1) == 1 {
+			select {
+			case <-req.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Write([]byte("fast"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithAttemptTimeout(50*time.Millisecond))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	start := time.Now()
+	resp, err := client.Do(req.WithContext(ctx))
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) < time.Second, "the slow attempt is cancelled")
+	assert.Equal(t, 2, Attempts(resp))
+	assert.NoError(t, ctx.Err(), "the request context is left alone")
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "fast", string(body))
+	resp.Body.Close()
+}
+
+func TestOverallTimeout(t *testing.T) {
+	var requestCount int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(10*time.Millisecond), retryOn5XX,
+		WithAttemptTimeout(40*time.Millisecond), WithOverallTimeout(150*time.Millisecond))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	start := time.Now()
+	_, err := client.Do(req)
+	elapsed := time.Since(start)
+
+	var deadlineErr *DeadlineError
+	assert.True(t, errors.As(err, &deadlineErr))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, elapsed < time.Second, "the retries are cut short, took %s", elapsed)
+	assert.True(t, atomic.LoadInt64(&requestCount) >= 2, "attempts time out on their own")
+	assert.NoError(t, req.Context().Err(), "the request context is left alone")
+}
+
+func TestOverallTimeoutCoversTheBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("fast"))
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithOverallTimeout(time.Second))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	_, hasDeadline := resp.Request.Context().Deadline()
+	assert.True(t, hasDeadline)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err, "the body can be read after Do returned")
+	assert.Equal(t, "fast", string(body))
+	resp.Body.Close()
+	assert.Error(t, resp.Request.Context().Err(), "closing the body releases the context")
+}
@@ -0,0 +1,85 @@
+package httpeeve
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+type contextKeyCollected struct{}
+
+// CollectedResponse is an intermediate response of a request that was kept by WithResponseCollector.
+type CollectedResponse struct {
+	Attempt    int
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Truncated tells whether Body is missing bytes because the memory budget ran out.
+	Truncated bool
+}
+
+// WithResponseCollector makes the client keep the responses it discards while retrying a request, so they can
+// be inspected with CollectedResponses. At most budget bytes of bodies are kept per request: whatever exceeds
+// it is drained without being kept, up to the limit of WithMaxDrain, which prevents running out of memory in long
+// retry sequences.
+func WithResponseCollector(budget int64) Option {
+	return func(c *BackoffClient) {
+		c.collectorBudget = budget
+	}
+}
+
+// CollectedResponses returns the intermediate responses kept for the request that resulted in resp, and
+// whether any of their bodies had to be truncated. It returns nothing unless WithResponseCollector is used.
+func CollectedResponses(resp *http.Response) ([]CollectedResponse, bool) {
+	collector, _ := valueOf(resp, contextKeyCollected{}).(*responseCollector)
+	if collector == nil {
+		return nil, false
+	}
+	return collector.responses, collector.truncated
+}
+
+type responseCollector struct {
+	budget    int64
+	used      int64
+	responses []CollectedResponse
+	truncated bool
+}
+
+// collect keeps what the budget allows of a discarded response and drains up to maxDrain bytes of the rest, or
+// all of it if maxDrain is 0, returning the number of bytes of its body that were read.
+func (rc *responseCollector) collect(attempt int, resp *http.Response, maxDrain int64) int64 {
+	if resp == nil {
+		return 0
+	}
+
+	collected := CollectedResponse{Attempt: attempt, StatusCode: resp.StatusCode, Header: resp.Header}
+	if resp.Body == nil {
+		rc.responses = append(rc.responses, collected)
+		return 0
+	}
+
+	var body bytes.Buffer
+	kept, _ := io.Copy(&body, io.LimitReader(resp.Body, rc.budget-rc.used))
+	var rest io.Reader = resp.Body
+	if maxDrain > 0 {
+		rest = io.LimitReader(rest, maxDrain)
+	}
+	drained, _ := io.Copy(ioutil.Discard, rest)
+	resp.Body.Close()
+
+	rc.used += kept
+	collected.Body = body.Bytes()
+	collected.Truncated = drained > 0
+	rc.truncated = rc.truncated || collected.Truncated
+	rc.responses = append(rc.responses, collected)
+
+	return kept + drained
+}
+
+func addCollectedToRequest(resp *http.Response, collector *responseCollector) {
+	if collector != nil && resp != nil && resp.Request != nil && resp.Request.Context() != nil {
+		resp.Request = resp.Request.WithContext(context.WithValue(resp.Request.Context(), contextKeyCollected{}, collector))
+	}
+}
@@ -0,0 +1,91 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCollectorTruncatesPastBudget(t *testing.T) {
+	bodies := []string{strings.Repeat("a", 6), strings.Repeat("b", 6), strings.Repeat("c", 6), "done"}
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < len(bodies) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write([]byte(bodies[requestCount-1]))
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithResponseCollector(10))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+
+	collected, truncated := CollectedResponses(resp)
+	assert.True(t, truncated)
+	assert.Len(t, collected, 3)
+
+	assert.Equal(t, 1, collected[0].Attempt)
+	assert.Equal(t, http.StatusServiceUnavailable, collected[0].StatusCode)
+	assert.Equal(t, "aaaaaa", string(collected[0].Body))
+	assert.False(t, collected[0].Truncated)
+
+	assert.Equal(t, "bbbb", string(collected[1].Body))
+	assert.True(t, collected[1].Truncated)
+
+	assert.Equal(t, "", string(collected[2].Body))
+	assert.True(t, collected[2].Truncated)
+
+	assert.Equal(t, int64(18), DrainedBytes(resp), "truncated bytes are still drained")
+}
+
+func TestResponseCollectorIsOptional(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+
+	collected, truncated := CollectedResponses(resp)
+	assert.Nil(t, collected)
+	assert.False(t, truncated)
+}
+
+func TestResponseCollectorDrainsNoMoreThanMaxDrain(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(make([]byte, 1<<20))
+			return
+		}
+		w.Write([]byte("fine"))
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithResponseCollector(10), WithMaxDrain(1024))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	collected, truncated := CollectedResponses(resp)
+	assert.True(t, truncated)
+	assert.Len(t, collected, 1)
+	assert.Len(t, collected[0].Body, 10)
+	assert.Equal(t, int64(10+1024), DrainedBytes(resp))
+}
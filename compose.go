@@ -0,0 +1,91 @@
+package httpeeve
+
+import "net/http"
+
+// FirstDecisive returns a Conditioner that asks conditioners in order and returns the decision of the first
+// one that does not Abstain. If all of them abstain, so does the returned Conditioner.
+func FirstDecisive(conditioners ...Conditioner) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		for _, conditioner := range conditioners {
+			if shouldRetry, err := conditioner(resp); err != errAbstain {
+				return shouldRetry, err
+			}
+		}
+
+		return Abstain()
+	}
+}
+
+// CombineConditioners returns a Conditioner that asks all of conditioners in order, with these precedences:
+//
+//   - the first unretriable error wins right away, without asking the remaining conditioners
+//   - otherwise the first retriable error wins
+//   - otherwise the response is accepted
+//
+// Conditioners that Abstain have no say. If all of them abstain, so does the returned Conditioner.
+func CombineConditioners(conditioners ...Conditioner) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		var retriable error
+		abstained := true
+		for _, conditioner := range conditioners {
+			shouldRetry, err := conditioner(resp)
+			switch {
+			case err == errAbstain:
+				continue
+			case err != nil && !shouldRetry:
+				return false, err
+			case err != nil && retriable == nil:
+				retriable = err
+			}
+			abstained = false
+		}
+
+		switch {
+		case retriable != nil:
+			return true, retriable
+		case abstained:
+			return Abstain()
+		default:
+			return OK()
+		}
+	}
+}
+
+// Chain returns a Conditioner that asks conditioners in order until one of them does not Abstain. It is
+// FirstDecisive under the name of the other combinators.
+func Chain(conditioners ...Conditioner) Conditioner {
+	return FirstDecisive(conditioners...)
+}
+
+// All returns a Conditioner that accepts a response only if all of conditioners accept it. It is
+// CombineConditioners under the name of the other combinators.
+func All(conditioners ...Conditioner) Conditioner {
+	return CombineConditioners(conditioners...)
+}
+
+// Any returns a Conditioner that accepts a response as soon as one of conditioners accepts it, asking them in
+// order. If none of them does, the decision of the first one that does not Abstain is returned. Conditioners
+// that Abstain have no say. If all of them abstain, so does the returned Conditioner.
+func Any(conditioners ...Conditioner) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		decided := false
+		var shouldRetry bool
+		var firstErr error
+		for _, conditioner := range conditioners {
+			retry, err := conditioner(resp)
+			switch {
+			case err == errAbstain:
+				continue
+			case err == nil:
+				return OK()
+			case !decided:
+				decided, shouldRetry, firstErr = true, retry, err
+			}
+		}
+
+		if !decided {
+			return Abstain()
+		}
+		return shouldRetry, firstErr
+	}
+}
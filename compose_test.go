@@ -0,0 +1,176 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func onlyStatus(code int, decision func() (bool, error)) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		if resp.StatusCode != code {
+			return Abstain()
+		}
+		return decision()
+	}
+}
+
+func TestFirstDecisive(t *testing.T) {
+	var asked []string
+	spy := func(name string, conditioner Conditioner) Conditioner {
+		return func(resp *http.Response) (bool, error) {
+			asked = append(asked, name)
+			return conditioner(resp)
+		}
+	}
+
+	conditioner := FirstDecisive(
+		spy("429", onlyStatus(429, func() (bool, error) { return RetriableError("slow down") })),
+		spy("404", onlyStatus(404, func() (bool, error) { return PermanentError("gone") })),
+		spy("5XX", retryOn5XX),
+	)
+
+	shouldRetry, err := conditioner(&http.Response{StatusCode: 404})
+	assert.False(t, shouldRetry)
+	assert.EqualError(t, err, "gone")
+	assert.Equal(t, []string{"429", "404"}, asked)
+
+	asked = nil
+	shouldRetry, err = conditioner(&http.Response{StatusCode: 503})
+	assert.True(t, shouldRetry)
+	assert.EqualError(t, err, "bad status code 503")
+	assert.Equal(t, []string{"429", "404", "5XX"}, asked)
+}
+
+func TestFirstDecisiveAbstainsWhenAllAbstain(t *testing.T) {
+	conditioner := FirstDecisive(onlyStatus(429, OK), onlyStatus(404, OK))
+	shouldRetry, err := conditioner(&http.Response{StatusCode: 200})
+	assert.False(t, shouldRetry)
+	assert.Equal(t, errAbstain, err)
+}
+
+func TestClientAcceptsAbstention(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, FirstDecisive(onlyStatus(500, OK)))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+}
+
+func TestCombineConditionersPermanentWins(t *testing.T) {
+	retry := func(*http.Response) (bool, error) { return RetriableError("retry") }
+	permanent := func(*http.Response) (bool, error) { return PermanentError("permanent") }
+	ok := func(*http.Response) (bool, error) { return OK() }
+	abstain := func(*http.Response) (bool, error) { return Abstain() }
+	resp := &http.Response{StatusCode: http.StatusOK}
+
+	for _, tc := range []struct {
+		name         string
+		conditioners []Conditioner
+		shouldRetry  bool
+		err          string
+	}{
+		{"retry then permanent", []Conditioner{retry, permanent}, false, "permanent"},
+		{"permanent then retry", []Conditioner{permanent, retry}, false, "permanent"},
+		{"ok then retry", []Conditioner{ok, retry, ok}, true, "retry"},
+		{"all ok", []Conditioner{ok, abstain, ok}, false, ""},
+		{"all abstain", []Conditioner{abstain, abstain}, false, errAbstain.Error()},
+	} {
+		shouldRetry, err := CombineConditioners(tc.conditioners...)(resp)
+		assert.Equal(t, tc.shouldRetry, shouldRetry, tc.name)
+		if tc.err == "" {
+			assert.NoError(t, err, tc.name)
+		} else {
+			assert.EqualError(t, err, tc.err, tc.name)
+		}
+	}
+}
+
+func TestCombineConditionersWithClient(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.Header().Set("X-Retry", "1")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	retryOnHeader := func(resp *http.Response) (bool, error) {
+		if resp.Header.Get("X-Retry") != "" {
+			return RetriableError("asked to retry")
+		}
+		return OK()
+	}
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, CombineConditioners(retryOn5XX, retryOnHeader))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+}
+
+func TestChain(t *testing.T) {
+	conditioner := Chain(onlyStatus(404, func() (bool, error) { return PermanentError("gone") }), retryOn5XX)
+
+	shouldRetry, err := conditioner(&http.Response{StatusCode: 404})
+	assert.False(t, shouldRetry)
+	assert.EqualError(t, err, "gone")
+
+	shouldRetry, err = conditioner(&http.Response{StatusCode: 503})
+	assert.True(t, shouldRetry)
+	assert.EqualError(t, err, "bad status code 503")
+}
+
+func TestAll(t *testing.T) {
+	retry := func(*http.Response) (bool, error) { return RetriableError("retry") }
+	ok := func(*http.Response) (bool, error) { return OK() }
+	resp := &http.Response{StatusCode: http.StatusOK}
+
+	shouldRetry, err := All(ok, retry)(resp)
+	assert.True(t, shouldRetry)
+	assert.EqualError(t, err, "retry")
+
+	shouldRetry, err = All(ok, ok)(resp)
+	assert.False(t, shouldRetry)
+	assert.NoError(t, err)
+}
+
+func TestAny(t *testing.T) {
+	retry := func(*http.Response) (bool, error) { return RetriableError("retry") }
+	permanent := func(*http.Response) (bool, error) { return PermanentError("permanent") }
+	ok := func(*http.Response) (bool, error) { return OK() }
+	abstain := func(*http.Response) (bool, error) { return Abstain() }
+	resp := &http.Response{StatusCode: http.StatusOK}
+
+	for _, tc := range []struct {
+		name         string
+		conditioners []Conditioner
+		shouldRetry  bool
+		err          string
+	}{
+		{"retry then ok", []Conditioner{retry, ok}, false, ""},
+		{"permanent then ok", []Conditioner{permanent, abstain, ok}, false, ""},
+		{"retry then permanent", []Conditioner{abstain, retry, permanent}, true, "retry"},
+		{"permanent then retry", []Conditioner{permanent, retry}, false, "permanent"},
+		{"all abstain", []Conditioner{abstain, abstain}, false, errAbstain.Error()},
+	} {
+		shouldRetry, err := Any(tc.conditioners...)(resp)
+		assert.Equal(t, tc.shouldRetry, shouldRetry, tc.name)
+		if tc.err == "" {
+			assert.NoError(t, err, tc.name)
+		} else {
+			assert.EqualError(t, err, tc.err, tc.name)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package httpeeve
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned, wrapped with the host, for requests that were turned away because the concurrency
+	// limit was reached and its queue was full, see WithConcurrencyLimit. Check for it with errors.Is.
+	ErrQueueFull = errors.New("request queue is full")
+	// ErrQueueTimeout is returned, wrapped with the host, for requests that waited in the queue of the concurrency
+	// limit for longer than its timeout, see WithConcurrencyLimit. Check for it with errors.Is.
+	ErrQueueTimeout = errors.New("timed out waiting in the request queue")
+)
+
+// WithConcurrencyLimit caps the requests the client sends or retries at the same time at maxInFlight, so that a
+// flaky upstream cannot exhaust the connection pool in a retry storm. A request holds its slot across all of its
+// attempts and the waits between them. While all slots are taken, up to queueSize further requests wait in
+// first-in, first-out order, at most for queueTimeout, or, if it is 0, for as long as their context allows.
+// Requests that find the queue full fail with ErrQueueFull, those that wait too long with ErrQueueTimeout.
+func WithConcurrencyLimit(maxInFlight int, queueSize int, queueTimeout time.Duration) Option {
+	return func(c *BackoffClient) {
+		c.concurrency = newConcurrencyLimiter(false, maxInFlight, queueSize, queueTimeout)
+	}
+}
+
+// WithHostConcurrencyLimit is like WithConcurrencyLimit, but every host has slots and a queue of its own.
+func WithHostConcurrencyLimit(maxInFlight int, queueSize int, queueTimeout time.Duration) Option {
+	return func(c *BackoffClient) {
+		c.concurrency = newConcurrencyLimiter(true, maxInFlight, queueSize, queueTimeout)
+	}
+}
+
+type concurrencyLimiter struct {
+	perHost      bool
+	maxInFlight  int
+	queueSize    int
+	queueTimeout time.Duration
+
+	mu         sync.Mutex
+	semaphores map[string]*semaphore
+}
+
+func newConcurrencyLimiter(perHost bool, maxInFlight, queueSize int, queueTimeout time.Duration) *concurrencyLimiter {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	return &concurrencyLimiter{
+		perHost:      perHost,
+		maxInFlight:  maxInFlight,
+		queueSize:    queueSize,
+		queueTimeout: queueTimeout,
+		semaphores:   map[string]*semaphore{},
+	}
+}
+
+// acquire waits for a slot for req, and returns the function that frees it.
+func (l *concurrencyLimiter) acquire(req *http.Request) (func(), error) {
+	var key string
+	if l.perHost {
+		key = req.URL.Host
+	}
+
+	l.mu.Lock()
+	sem := l.semaphores[key]
+	if sem == nil {
+		sem = &semaphore{size: l.maxInFlight, queueSize: l.queueSize}
+		l.semaphores[key] = sem
+	}
+	l.mu.Unlock()
+
+	if err := sem.acquire(req.Context(), l.queueTimeout); err != nil {
+		if errors.Is(err, ErrQueueFull) || errors.Is(err, ErrQueueTimeout) {
+			err = fmt.Errorf("%s: %w", req.URL.Host, err)
+		}
+		return nil, err
+	}
+	return sem.release, nil
+}
+
+// semaphore hands out size slots, queueing up to queueSize waiters in order.
+type semaphore struct {
+	size      int
+	queueSize int
+
+	mu      sync.Mutex
+	taken   int
+	waiters list.List
+}
+
+func (s *semaphore) acquire(ctx context.Context, timeout time.Duration) error {
+	s.mu.Lock()
+	if s.taken < s.size && s.waiters.Len() == 0 {
+		s.taken++
+		s.mu.Unlock()
+		return nil
+	}
+	if s.waiters.Len() >= s.queueSize {
+		s.mu.Unlock()
+		return ErrQueueFull
+	}
+	ready := make(chan struct{})
+	waiter := s.waiters.PushBack(ready)
+	s.mu.Unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	var err error
+	select {
+	case <-ready:
+		return nil
+	case <-expired:
+		err = ErrQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s.mu.Lock()
+	select {
+	case <-ready:
+		// the slot was handed over while giving up, pass it on
+		s.mu.Unlock()
+		s.release()
+	default:
+		s.waiters.Remove(waiter)
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// release frees a slot by handing it over to the first waiter, if there is one.
+func (s *semaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if first := s.waiters.Front(); first != nil {
+		s.waiters.Remove(first)
+		close(first.Value.(chan struct{}))
+		return
+	}
+	s.taken--
+}
@@ -0,0 +1,121 @@
+package httpeeve
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingServer answers every request once unblock is closed, and reports the requests it got on arrived.
+func blockingServer() (server *httptest.Server, arrived chan string, unblock chan struct{}) {
+	arrived, unblock = make(chan string, 10), make(chan struct{})
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		arrived <- req.URL.Path
+		<-unblock
+	}))
+	return server, arrived, unblock
+}
+
+func TestConcurrencyLimitQueuesInOrder(t *testing.T) {
+	server, arrived, unblock := blockingServer()
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithConcurrencyLimit(1, 2, 0))
+
+	var wg sync.WaitGroup
+	send := func(path string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+			_, err := client.Do(req)
+			assert.NoError(t, err, path)
+		}()
+	}
+
+	send("/first")
+	assert.Equal(t, "/first", <-arrived)
+	send("/second")
+	time.Sleep(10 * time.Millisecond)
+	send("/third")
+	time.Sleep(10 * time.Millisecond)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/fourth", nil)
+	_, err := client.Do(req)
+	assert.True(t, errors.Is(err, ErrQueueFull))
+
+	close(unblock)
+	wg.Wait()
+	assert.Equal(t, "/second", <-arrived)
+	assert.Equal(t, "/third", <-arrived)
+}
+
+func TestConcurrencyLimitQueueTimeout(t *testing.T) {
+	server, arrived, unblock := blockingServer()
+	defer server.Close()
+	defer close(unblock)
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithConcurrencyLimit(1, 1, 10*time.Millisecond))
+
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		_, _ = client.Do(req)
+	}()
+	<-arrived
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.True(t, errors.Is(err, ErrQueueTimeout))
+	assert.Contains(t, err.Error(), req.URL.Host)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.Do(req.WithContext(ctx))
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestHostConcurrencyLimit(t *testing.T) {
+	slow, arrived, unblock := blockingServer()
+	defer slow.Close()
+	defer close(unblock)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer fast.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithHostConcurrencyLimit(1, 0, 0))
+
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, slow.URL, nil)
+		_, _ = client.Do(req)
+	}()
+	<-arrived
+
+	req, _ := http.NewRequest(http.MethodGet, slow.URL, nil)
+	_, err := client.Do(req)
+	assert.True(t, errors.Is(err, ErrQueueFull))
+
+	req, _ = http.NewRequest(http.MethodGet, fast.URL, nil)
+	_, err = client.Do(req)
+	assert.NoError(t, err, "other hosts have slots of their own")
+}
+
+func TestSemaphoreHandsOverSlots(t *testing.T) {
+	sem := &semaphore{size: 1, queueSize: 1}
+	assert.NoError(t, sem.acquire(context.Background(), 0))
+
+	acquired := make(chan error)
+	go func() { acquired <- sem.acquire(context.Background(), 0) }()
+	time.Sleep(10 * time.Millisecond)
+	sem.release()
+	assert.NoError(t, <-acquired)
+	assert.Equal(t, 1, sem.taken)
+
+	sem.release()
+	assert.Equal(t, 0, sem.taken)
+}
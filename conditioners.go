@@ -0,0 +1,297 @@
+package httpeeve
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type failoverError struct {
+	header  string
+	primary *url.URL
+}
+
+func (e *failoverError) Error() string {
+	return fmt.Sprintf("failover to %s requested via %s header", e.primary.Host, e.header)
+}
+
+// RetryOnFailoverHint is meant for active-passive setups, where a replica may answer with a header hinting
+// to use the primary instead. Whenever a response carries a non-empty hint header, the request is retried with
+// the scheme and host of primary, for all of its later attempts; the request passed to Do is left as it is. All
+// other responses are judged by conditioner.
+func RetryOnFailoverHint(hint string, primary *url.URL, conditioner Conditioner) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		if resp.Header.Get(hint) == "" {
+			return conditioner(resp)
+		}
+
+		return true, &failoverError{header: hint, primary: primary}
+	}
+}
+
+func rewriteToPrimary(req *http.Request, primary *url.URL) {
+	rewritten := *req.URL
+	rewritten.Scheme = primary.Scheme
+	rewritten.Host = primary.Host
+	req.URL = &rewritten
+	req.Host = ""
+}
+
+// RetryOnAcceptedStatus returns a Conditioner for APIs that answer with a 2XX status such as 202 Accepted
+// while a request is still being processed. Responses with one of the given codes are retried, which
+// pairs well with a constant polling backoff. Other 2XXs are accepted and everything else results in an
+// unretriable error.
+func RetryOnAcceptedStatus(codes ...int) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		for _, code := range codes {
+			if resp.StatusCode == code {
+				return RetriableErrorf("status code %d, still processing", resp.StatusCode)
+			}
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return OK()
+		}
+
+		return PermanentErrorf("bad status code %d", resp.StatusCode)
+	}
+}
+
+// RetryOnStatusRange is like RetryOnStatus, but retries every status code from lo to hi, both included, for
+// instance RetryOnStatusRange(500, 599).
+func RetryOnStatusRange(lo, hi int) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		if resp.StatusCode >= lo && resp.StatusCode <= hi {
+			return RetriableErrorf("bad status code %d", resp.StatusCode)
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return OK()
+		}
+
+		return PermanentErrorf("bad status code %d", resp.StatusCode)
+	}
+}
+
+// RetryOn429 returns a Conditioner that retries 429 Too Many Requests, accepts 2XXs and gives up on everything
+// else. Pair it with HonorRetryAfter to wait as long as the server asks.
+func RetryOn429() Conditioner {
+	return RetryOnStatus(http.StatusTooManyRequests)
+}
+
+// RetryOnJSONField returns a Conditioner for APIs that report transient errors in a JSON body, retrying
+// responses whose field at path, such as "error.code", equals value, as in {"error":{"code":"RETRY_LATER"}}.
+// Path segments are separated by dots and index objects only. At most DefaultMaxBodySize bytes of the body are
+// read, and restored afterwards. Any other response, including one whose body is not JSON, makes it Abstain,
+// so that it can be combined with status-based Conditioners using FirstDecisive or CombineConditioners.
+func RetryOnJSONField(path string, value interface{}) Conditioner {
+	want, err := json.Marshal(value)
+	return func(resp *http.Response) (bool, error) {
+		if err != nil {
+			return PermanentErrorf("marshaling %v to compare with %s: %s", value, path, err)
+		}
+
+		body, _, readErr := peekBodyUpTo(resp, DefaultMaxBodySize)
+		if readErr != nil {
+			return RetriableErrorf("reading body: %s", readErr)
+		}
+
+		var field interface{}
+		if json.Unmarshal(body, &field) != nil {
+			return Abstain()
+		}
+		for _, key := range strings.Split(path, ".") {
+			object, ok := field.(map[string]interface{})
+			if !ok {
+				return Abstain()
+			}
+			if field, ok = object[key]; !ok {
+				return Abstain()
+			}
+		}
+
+		if got, _ := json.Marshal(field); bytes.Equal(got, want) {
+			return RetriableErrorf("%s is %s", path, want)
+		}
+		return Abstain()
+	}
+}
+
+// RetryIfSlow wraps conditioner for hedging-like behaviour on reads: a response accepted by conditioner that
+// took longer than slo to arrive is retried, hoping for a faster node, at most maxRetries times. Only idempotent
+// requests are retried, and a retry that turns out not to be faster than its predecessor is accepted anyway.
+func RetryIfSlow(slo time.Duration, maxRetries int, conditioner Conditioner) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		shouldRetry, err := conditioner(resp)
+		if err != nil {
+			return shouldRetry, err
+		}
+
+		attempt := attemptOf(resp)
+		if attempt.number == 0 || attempt.number > maxRetries || !isIdempotent(resp.Request.Method) {
+			return OK()
+		}
+
+		latency := attempt.now().Sub(attempt.start)
+		if latency <= slo || (attempt.number > 1 && latency >= attempt.previousLatency) {
+			return OK()
+		}
+
+		return RetriableErrorf("response took %s, exceeding the latency SLO of %s", latency, slo)
+	}
+}
+
+// RetryOnGatewayErrors returns a Conditioner that tells gateway errors (502, 503 and 504) without a body,
+// which are almost always transient, apart from those with an error body that might be meaningful to the
+// application. The former are retried up to emptyRetries times, the latter up to bodiedRetries times. Other
+// responses are treated like in NewDefaultBackoffClient5XX.
+func RetryOnGatewayErrors(emptyRetries, bodiedRetries int) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		switch resp.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		default:
+			return retryOn5XX(resp)
+		}
+
+		retries, kind := bodiedRetries, "with body"
+		if resp.ContentLength == 0 {
+			retries, kind = emptyRetries, "without body"
+		} else if resp.ContentLength < 0 {
+			if body, err := peekBody(resp); err == nil && len(body) == 0 {
+				retries, kind = emptyRetries, "without body"
+			}
+		}
+
+		if attemptOf(resp).number > retries {
+			return PermanentErrorf("bad status code %d %s", resp.StatusCode, kind)
+		}
+
+		return RetriableErrorf("bad status code %d %s", resp.StatusCode, kind)
+	}
+}
+
+// RequireLocationHeader returns a Conditioner for create endpoints, which are expected to answer 201 Created
+// or 202 Accepted with a Location header. A response with either status but without the header is likely
+// degraded and is retried up to maxRetries times, after which it results in an unretriable error. Other
+// responses are treated like in NewDefaultBackoffClient5XX. Create endpoints are usually not idempotent, so
+// their requests are only retried by clients created with WithIdempotentOnly(false).
+func RequireLocationHeader(maxRetries int) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		switch resp.StatusCode {
+		case http.StatusCreated, http.StatusAccepted:
+		default:
+			return retryOn5XX(resp)
+		}
+
+		if resp.Header.Get("Location") != "" {
+			return OK()
+		}
+
+		if attemptOf(resp).number > maxRetries {
+			return PermanentErrorf("status code %d without Location header", resp.StatusCode)
+		}
+
+		return RetriableErrorf("status code %d without Location header", resp.StatusCode)
+	}
+}
+
+// RetryOnExpiringCertificate wraps conditioner for setups where some nodes behind a proxy may still serve a
+// certificate that is about to expire. A response accepted by conditioner whose leaf certificate expires
+// within threshold is retried, hoping to reach a renewed node, at most maxRetries times, after which it is
+// accepted anyway. This is only worth it when retries may land on another connection and thus another node.
+func RetryOnExpiringCertificate(threshold time.Duration, maxRetries int, conditioner Conditioner) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		shouldRetry, err := conditioner(resp)
+		if err != nil {
+			return shouldRetry, err
+		}
+
+		attempt := attemptOf(resp)
+		if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 || attempt.number > maxRetries {
+			return OK()
+		}
+
+		leaf := resp.TLS.PeerCertificates[0]
+		if remaining := leaf.NotAfter.Sub(attempt.now()); remaining <= threshold {
+			return RetriableErrorf("certificate of %s expires in %s", leaf.Subject.CommonName, remaining.Round(time.Second))
+		}
+
+		return OK()
+	}
+}
+
+// MaxResponseSize returns a Conditioner that guards against downloading huge payloads: a response whose
+// Content-Length exceeds maxBytes results in an unretriable error. It abstains from all other responses,
+// including those of unknown length, so it is meant to go first in FirstDecisive.
+func MaxResponseSize(maxBytes int64) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		if resp.ContentLength > maxBytes {
+			return PermanentErrorf("response of %d bytes exceeds the maximum of %d", resp.ContentLength, maxBytes)
+		}
+
+		return Abstain()
+	}
+}
+
+// Validate returns a Conditioner for custom success criteria. A 2XX response is passed to validator, and
+// retried up to maxRetries times if it returns an error, after which the error is returned. The body is
+// buffered beforehand and restored afterwards, so validator can read it and so can the caller. A body longer
+// than DefaultMaxBodySize fails the validation without being passed to validator. Other responses are
+// treated like in NewDefaultBackoffClient5XX.
+func Validate(maxRetries int, validator func(resp *http.Response) error) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return retryOn5XX(resp)
+		}
+
+		body, tooLong, err := peekBodyUpTo(resp, DefaultMaxBodySize)
+		if err != nil {
+			return RetriableErrorf("reading body: %s", err)
+		}
+
+		if tooLong {
+			err = fmt.Errorf("body is longer than %d bytes", DefaultMaxBodySize)
+		} else {
+			err = validator(resp)
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		if err == nil {
+			return OK()
+		}
+
+		if attemptOf(resp).number > maxRetries {
+			return false, err
+		}
+
+		return true, err
+	}
+}
+
+// DefaultMaxBodySize is how much of a response body BodyConditioner reads at most.
+const DefaultMaxBodySize = 4 << 20
+
+// BodyConditioner returns a Conditioner for APIs that report errors in the body of otherwise successful
+// responses, such as a 200 with {"status":"error","retryable":true}. The body is read, at most
+// DefaultMaxBodySize bytes of it, and passed to judge, which decides like a Conditioner. The body is restored
+// afterwards, so the caller can still read it in full.
+func BodyConditioner(judge func(body []byte) (bool, error)) Conditioner {
+	return BodyConditionerUpTo(DefaultMaxBodySize, judge)
+}
+
+// BodyConditionerUpTo is like BodyConditioner, but reads at most maxBytes of the body. judge only sees the
+// beginning of longer bodies.
+func BodyConditionerUpTo(maxBytes int64, judge func(body []byte) (bool, error)) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		body, _, err := peekBodyUpTo(resp, maxBytes)
+		if err != nil {
+			return RetriableErrorf("reading body: %s", err)
+		}
+
+		return judge(body)
+	}
+}
@@ -0,0 +1,461 @@
+package httpeeve
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryOnFailoverHint(t *testing.T) {
+	var primaryCount, replicaCount int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		primaryCount++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer primary.Close()
+
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		replicaCount++
+		w.Header().Set("X-Use-Primary", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer replica.Close()
+
+	primaryURL, _ := url.Parse(primary.URL)
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, RetryOnFailoverHint("X-Use-Primary", primaryURL, func(resp *http.Response) (bool, error) {
+		if resp.StatusCode != http.StatusOK {
+			return PermanentErrorf("bad status code %d", resp.StatusCode)
+		}
+		return OK()
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, replica.URL+"/things?id=1", nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 1, replicaCount)
+	assert.Equal(t, 1, primaryCount)
+	assert.Equal(t, "/things", resp.Request.URL.Path)
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Equal(t, replica.URL+"/things?id=1", req.URL.String())
+
+	// the request can be sent again, to the replica first
+	resp, err = client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 2, replicaCount)
+	assert.Equal(t, 2, primaryCount)
+}
+
+func TestRetryOnAcceptedStatus(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Millisecond), RetryOnAcceptedStatus(http.StatusAccepted))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, 3, Attempts(resp))
+}
+
+func TestRetryOnAcceptedStatusRejectsNon2XX(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Millisecond), RetryOnAcceptedStatus(http.StatusAccepted))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.EqualError(t, err, "bad status code 400")
+	assert.Equal(t, 1, Attempts(resp))
+}
+
+func TestRetryIfSlow(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			time.Sleep(50 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, RetryIfSlow(20*time.Millisecond, 3, retryOn5XX))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+
+	req, _ = http.NewRequest(http.MethodPost, server.URL, nil)
+	requestCount = 0
+	resp, err = client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, Attempts(resp), "non-idempotent requests are not retried")
+}
+
+func TestRetryIfSlowAcceptsRetriesThatAreNotFaster(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		time.Sleep(time.Duration(10+10*requestCount) * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, RetryIfSlow(10*time.Millisecond, 5, retryOn5XX))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+}
+
+func TestRetryIfSlowUsesClientClock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	pinned := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, RetryIfSlow(20*time.Millisecond, 3, retryOn5XX),
+		WithNow(func() time.Time { return pinned }))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, Attempts(resp), "no time passes on the clock of the client")
+}
+
+func TestRetryOnGatewayErrors(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusBadGateway)
+		if req.URL.Path == "/bodied" {
+			w.Write([]byte(`{"error":"upstream rejected the order"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, RetryOnGatewayErrors(4, 1))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/empty", nil)
+	resp, err := client.Do(req)
+	assert.EqualError(t, err, "bad status code 502 without body")
+	assert.Equal(t, 5, Attempts(resp))
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/bodied", nil)
+	resp, err = client.Do(req)
+	assert.EqualError(t, err, "bad status code 502 with body")
+	assert.Equal(t, 2, Attempts(resp))
+}
+
+func TestRequireLocationHeader(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount > 1 {
+			w.Header().Set("Location", "/things/1")
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Millisecond), RequireLocationHeader(2), WithIdempotentOnly(false))
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "/things/1", resp.Header.Get("Location"))
+	assert.Equal(t, 2, Attempts(resp))
+}
+
+func TestRequireLocationHeaderGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Millisecond), RequireLocationHeader(2), WithIdempotentOnly(false))
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.EqualError(t, err, "status code 201 without Location header")
+	assert.Equal(t, 3, Attempts(resp))
+}
+
+func TestRetryOnExpiringCertificate(t *testing.T) {
+	conditioner := RetryOnExpiringCertificate(24*time.Hour, 2, retryOn5XX)
+	responseWithCertificate := func(attempt int, expiresIn time.Duration) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+		req = req.WithContext(context.WithValue(context.Background(), contextKeyAttempt{}, attemptInfo{number: attempt}))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Request:    req,
+			TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{
+				Subject:  pkix.Name{CommonName: "example.com"},
+				NotAfter: time.Now().Add(expiresIn),
+			}}},
+		}
+	}
+
+	shouldRetry, err := conditioner(responseWithCertificate(1, time.Hour))
+	assert.True(t, shouldRetry)
+	assert.True(t, strings.HasPrefix(err.Error(), "certificate of example.com expires in"))
+
+	shouldRetry, err = conditioner(responseWithCertificate(1, 30*24*time.Hour))
+	assert.False(t, shouldRetry)
+	assert.NoError(t, err)
+
+	shouldRetry, err = conditioner(responseWithCertificate(3, time.Hour))
+	assert.False(t, shouldRetry, "accepted once the retries are used up")
+	assert.NoError(t, err)
+
+	shouldRetry, err = conditioner(&http.Response{StatusCode: http.StatusOK})
+	assert.False(t, shouldRetry, "accepted without TLS")
+	assert.NoError(t, err)
+}
+
+func TestRetryOnExpiringCertificateUsesClientClock(t *testing.T) {
+	notAfter := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := func() time.Time { return notAfter.Add(-time.Hour) }
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	req = req.WithContext(context.WithValue(context.Background(), contextKeyAttempt{}, attemptInfo{number: 1, now: now}))
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Request:    req,
+		TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{
+			Subject:  pkix.Name{CommonName: "example.com"},
+			NotAfter: notAfter,
+		}}},
+	}
+
+	shouldRetry, err := RetryOnExpiringCertificate(24*time.Hour, 2, retryOn5XX)(resp)
+	assert.True(t, shouldRetry)
+	assert.EqualError(t, err, "certificate of example.com expires in 1h0m0s")
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/huge" {
+			w.Header().Set("Content-Length", "1048576")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), FirstDecisive(MaxResponseSize(1024), retryOn5XX))
+
+	req, _ := http.NewRequest(http.MethodHead, server.URL+"/huge", nil)
+	resp, err := client.Do(req)
+	assert.EqualError(t, err, "response of 1048576 bytes exceeds the maximum of 1024")
+	assert.Equal(t, 1, Attempts(resp))
+
+	req, _ = http.NewRequest(http.MethodHead, server.URL+"/small", nil)
+	resp, err = client.Do(req)
+	assert.EqualError(t, err, "bad status code 503")
+	assert.Equal(t, 3, Attempts(resp))
+}
+
+func TestMaxResponseSizeDefersOnUnknownLength(t *testing.T) {
+	shouldRetry, err := MaxResponseSize(1024)(&http.Response{StatusCode: http.StatusOK, ContentLength: -1})
+	assert.False(t, shouldRetry)
+	assert.Equal(t, errAbstain, err)
+}
+
+func TestValidate(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.Write([]byte(`{"items":null}`))
+			return
+		}
+		w.Write([]byte(`{"items":[1,2]}`))
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, Validate(2, func(resp *http.Response) error {
+		var page struct{ Items []int }
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			return err
+		}
+		if page.Items == nil {
+			return errors.New("page without items")
+		}
+		return nil
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, `{"items":[1,2]}`, string(body), "the body is restored after validation")
+}
+
+func TestValidateBodyTooLong(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), DefaultMaxBodySize+1))
+	}))
+	defer server.Close()
+
+	var validated int
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, Validate(1, func(resp *http.Response) error {
+		validated++
+		return nil
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.EqualError(t, err, fmt.Sprintf("body is longer than %d bytes", DefaultMaxBodySize))
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Zero(t, validated)
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Len(t, body, DefaultMaxBodySize+1, "the whole body is left to the caller")
+}
+
+func TestBodyConditioner(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.Write([]byte(`{"status":"error","retryable":true}`))
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, BodyConditioner(func(body []byte) (bool, error) {
+		var payload struct {
+			Status    string
+			Retryable bool
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return PermanentErrorf("parsing body: %s", err)
+		}
+		if payload.Status == "error" {
+			return payload.Retryable, errors.New("error payload")
+		}
+		return OK()
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"status":"ok"}`, string(body))
+}
+
+func TestBodyConditionerUpToRestoresLongBodies(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("0123456789"))}
+
+	var judged string
+	_, err := BodyConditionerUpTo(4, func(body []byte) (bool, error) {
+		judged = string(body)
+		return OK()
+	})(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123", judged)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", string(body))
+}
+
+func TestRetryOnStatusRange(t *testing.T) {
+	conditioner := RetryOnStatusRange(500, 504)
+	for _, tc := range []struct {
+		status      int
+		shouldRetry bool
+		err         bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusInternalServerError, true, true},
+		{http.StatusGatewayTimeout, true, true},
+		{http.StatusHTTPVersionNotSupported, false, true},
+		{http.StatusNotFound, false, true},
+	} {
+		shouldRetry, err := conditioner(&http.Response{StatusCode: tc.status})
+		assert.Equal(t, tc.shouldRetry, shouldRetry, tc.status)
+		assert.Equal(t, tc.err, err != nil, tc.status)
+	}
+}
+
+func TestRetryOnJSONField(t *testing.T) {
+	conditioner := RetryOnJSONField("error.code", "RETRY_LATER")
+	for _, tc := range []struct {
+		body        string
+		shouldRetry bool
+		err         error
+	}{
+		{`{"error":{"code":"RETRY_LATER"}}`, true, errors.New(`error.code is "RETRY_LATER"`)},
+		{`{"error":{"code":"INVALID"}}`, false, errAbstain},
+		{`{"error":"RETRY_LATER"}`, false, errAbstain},
+		{`{"status":"ok"}`, false, errAbstain},
+		{`not json`, false, errAbstain},
+	} {
+		resp := &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(tc.body))}
+		shouldRetry, err := conditioner(resp)
+		assert.Equal(t, tc.shouldRetry, shouldRetry, tc.body)
+		assert.EqualError(t, err, tc.err.Error(), tc.body)
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, tc.body, string(body), "the body is restored")
+	}
+
+	shouldRetry, err := RetryOnJSONField("retry_in", 5)(&http.Response{Body: ioutil.NopCloser(strings.NewReader(`{"retry_in":5.0}`))})
+	assert.True(t, shouldRetry)
+	assert.EqualError(t, err, "retry_in is 5", "numbers compare by value")
+}
+
+func TestStandardConditionersCompose(t *testing.T) {
+	conditioner := FirstDecisive(RetryOnJSONField("status", "RETRY_LATER"), RetryOn429())
+
+	shouldRetry, err := conditioner(&http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(`{"status":"RETRY_LATER"}`))})
+	assert.True(t, shouldRetry)
+	assert.Error(t, err)
+
+	shouldRetry, err = conditioner(&http.Response{StatusCode: http.StatusTooManyRequests, Body: ioutil.NopCloser(strings.NewReader(""))})
+	assert.True(t, shouldRetry)
+	assert.Error(t, err)
+
+	shouldRetry, err = conditioner(&http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(`{"status":"ok"}`))})
+	assert.False(t, shouldRetry)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,108 @@
+package httpeeve
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// WithDeadlineClamp makes sure the wait before a retry never takes more than fraction of the time left until
+// the deadline of the request context, so that a single long sleep cannot eat up the whole budget.
+func WithDeadlineClamp(fraction float64) Option {
+	return func(c *BackoffClient) {
+		c.deadlineFraction = fraction
+	}
+}
+
+// ClampToDeadline wraps b so that none of its intervals exceeds fraction of the time left until the deadline
+// of ctx. Intervals are left alone when ctx has no deadline.
+func ClampToDeadline(ctx context.Context, b backoff.BackOff, fraction float64) backoff.BackOff {
+	return &deadlineClampBackOff{BackOff: b, ctx: ctx, fraction: fraction, now: time.Now}
+}
+
+type deadlineClampBackOff struct {
+	backoff.BackOff
+	ctx      context.Context
+	fraction float64
+	now      func() time.Time
+}
+
+func (b *deadlineClampBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next == backoff.Stop {
+		return next
+	}
+
+	deadline, ok := b.ctx.Deadline()
+	if !ok {
+		return next
+	}
+
+	remaining := deadline.Sub(b.now())
+	if remaining <= 0 {
+		return 0
+	}
+
+	if limit := time.Duration(float64(remaining) * b.fraction); next > limit {
+		return limit
+	}
+	return next
+}
+
+// DeadlineFormat formats the time remaining until a deadline as a header value.
+type DeadlineFormat func(remaining time.Duration) string
+
+// MillisecondsFormat formats the time remaining until a deadline as a whole number of milliseconds.
+func MillisecondsFormat(remaining time.Duration) string {
+	return strconv.FormatInt(int64(remaining/time.Millisecond), 10)
+}
+
+// GRPCTimeoutFormat formats the time remaining until a deadline like the grpc-timeout header of gRPC: at most
+// eight digits followed by a unit, using the finest unit the value fits in.
+func GRPCTimeoutFormat(remaining time.Duration) string {
+	const maxValue = 99999999
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{{"n", time.Nanosecond}, {"u", time.Microsecond}, {"m", time.Millisecond}, {"S", time.Second}, {"M", time.Minute}} {
+		if value := remaining / unit.size; value <= maxValue {
+			return strconv.FormatInt(int64(value), 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(int64(remaining/time.Hour), 10) + "H"
+}
+
+// WithDeadlinePropagation tells the server how much time is left until the deadline of the request context, so
+// that it can abandon work that will not make it back in time. Every attempt carries the time remaining when
+// it is sent in header, formatted by format, for instance MillisecondsFormat for an X-Deadline header or
+// GRPCTimeoutFormat for grpc-timeout. Requests without a deadline are sent unchanged.
+func WithDeadlinePropagation(header string, format DeadlineFormat) Option {
+	return func(c *BackoffClient) {
+		c.deadlineHeader, c.deadlineFormat = header, format
+	}
+}
+
+func (c *BackoffClient) propagateDeadline(req *http.Request) {
+	if c.deadlineFormat == nil {
+		return
+	}
+
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return
+	}
+
+	remaining := deadline.Sub(c.now())
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	req.Header = req.Header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set(c.deadlineHeader, c.deadlineFormat(remaining))
+}
@@ -0,0 +1,118 @@
+package httpeeve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClampToDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	b := ClampToDeadline(ctx, backoff.NewConstantBackOff(time.Hour), 0.5)
+
+	first := b.NextBackOff()
+	assert.True(t, first <= 100*time.Millisecond && first > 80*time.Millisecond, first.String())
+
+	time.Sleep(first)
+	second := b.NextBackOff()
+	assert.True(t, second < first, "intervals shrink as the deadline approaches")
+	assert.True(t, second <= 50*time.Millisecond, second.String())
+}
+
+func TestClampToDeadlineWithoutDeadline(t *testing.T) {
+	b := ClampToDeadline(context.Background(), backoff.NewConstantBackOff(time.Hour), 0.5)
+	assert.Equal(t, time.Hour, b.NextBackOff())
+}
+
+func TestWithDeadlineClamp(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Hour), retryOn5XX, WithDeadlineClamp(0.25), WithEvents(10))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req.WithContext(ctx))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, Attempts(resp))
+
+	var delays []time.Duration
+	for len(client.Events()) > 0 {
+		if event := <-client.Events(); event.Type == Retrying {
+			delays = append(delays, event.Delay)
+		}
+	}
+	assert.Len(t, delays, 2)
+	assert.True(t, delays[0] <= 100*time.Millisecond)
+	assert.True(t, delays[1] < delays[0])
+}
+
+func TestDeadlinePropagation(t *testing.T) {
+	var deadlines []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		deadline, _ := strconv.Atoi(req.Header.Get("X-Deadline"))
+		deadlines = append(deadlines, deadline)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(backoff.NewConstantBackOff(20*time.Millisecond), 2), retryOn5XX,
+		WithDeadlinePropagation("X-Deadline", MillisecondsFormat))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req.WithContext(ctx))
+	assert.EqualError(t, err, "bad status code 503")
+
+	if assert.Len(t, deadlines, 3) {
+		assert.True(t, deadlines[0] > deadlines[1] && deadlines[1] > deadlines[2], "the deadline shrinks: %v", deadlines)
+		assert.True(t, deadlines[0] > 59000 && deadlines[0] <= 60000, deadlines[0])
+	}
+	assert.Empty(t, req.Header.Get("X-Deadline"), "the request of the caller is left alone")
+}
+
+func TestDeadlinePropagationUsesClientClock(t *testing.T) {
+	var deadlines []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		deadlines = append(deadlines, req.Header.Get("X-Deadline"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX,
+		WithDeadlinePropagation("X-Deadline", MillisecondsFormat), WithNow(func() time.Time { return deadline.Add(-30 * time.Second) }))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req.WithContext(ctx))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"30000"}, deadlines)
+}
+
+func TestGRPCTimeoutFormat(t *testing.T) {
+	assert.Equal(t, "0n", GRPCTimeoutFormat(0))
+	assert.Equal(t, "1500000u", GRPCTimeoutFormat(1500*time.Millisecond))
+	assert.Equal(t, "30000000u", GRPCTimeoutFormat(30*time.Second))
+	assert.Equal(t, "100000S", GRPCTimeoutFormat(100000*time.Second))
+}
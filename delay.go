@@ -0,0 +1,87 @@
+package httpeeve
+
+import (
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// delaySuggestion is returned by Conditioners that want to influence how long the client waits before
+// the next attempt. The client unwraps it, so callers only ever see err. adjust receives the interval the
+// backoff computed and the current time according to the clock of the client.
+type delaySuggestion struct {
+	err    error
+	adjust func(next time.Duration, now time.Time) time.Duration
+}
+
+func (s *delaySuggestion) Error() string {
+	return s.err.Error()
+}
+
+// suggestingBackOff applies the most recent delay suggestion to the interval of the wrapped backoff.
+// It is created per request, so suggestions never leak into other requests, unless they are learned.
+type suggestingBackOff struct {
+	backoff.BackOff
+	adjust func(next time.Duration, now time.Time) time.Duration
+	now    func() time.Time
+
+	// learned is set when suggestions are learned per host.
+	learned *learnedDelays
+	host    string
+
+	// immediate makes the next retry go without any delay, such as after renewing expired credentials.
+	immediate bool
+}
+
+func (b *suggestingBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next == backoff.Stop {
+		return next
+	}
+
+	if b.immediate {
+		b.immediate, b.adjust = false, nil
+		return 0
+	}
+
+	if b.adjust == nil {
+		if b.learned != nil {
+			if learned := b.learned.get(b.host); learned > next {
+				return learned
+			}
+		}
+		return next
+	}
+
+	next = b.adjust(next, b.now())
+	b.adjust = nil
+	if b.learned != nil {
+		b.learned.learn(b.host, next)
+	}
+	return next
+}
+
+// suggest remembers the suggestions carried by err, if any, and returns the error to report instead.
+// Suggestions nested by wrapping Conditioners are applied from the innermost to the outermost.
+func (b *suggestingBackOff) suggest(err error) error {
+	var adjustments []func(time.Duration, time.Time) time.Duration
+	for {
+		suggestion, ok := err.(*delaySuggestion)
+		if !ok {
+			break
+		}
+		adjustments = append(adjustments, suggestion.adjust)
+		err = suggestion.err
+	}
+
+	if len(adjustments) > 0 {
+		b.adjust = func(next time.Duration, now time.Time) time.Duration {
+			for i := len(adjustments) - 1; i >= 0; i-- {
+				next = adjustments[i](next, now)
+			}
+			return next
+		}
+	}
+
+	return err
+}
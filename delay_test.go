@@ -0,0 +1,22 @@
+package httpeeve
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNestedDelaySuggestions(t *testing.T) {
+	double := func(next time.Duration, _ time.Time) time.Duration { return 2 * next }
+	plusOne := func(next time.Duration, _ time.Time) time.Duration { return next + time.Millisecond }
+	err := &delaySuggestion{err: &delaySuggestion{err: errors.New("bad"), adjust: double}, adjust: plusOne}
+
+	b := &suggestingBackOff{BackOff: backoff.NewConstantBackOff(10 * time.Millisecond), now: time.Now}
+	assert.EqualError(t, b.suggest(err), "bad")
+	assert.IsType(t, errors.New(""), b.suggest(err))
+	assert.Equal(t, 21*time.Millisecond, b.NextBackOff(), "the inner suggestion applies first")
+	assert.Equal(t, 10*time.Millisecond, b.NextBackOff())
+}
@@ -0,0 +1,374 @@
+// Package delivery sends requests through an httpeeve client with guaranteed delivery, as needed for webhooks:
+// requests are persisted to a Store when they are enqueued, and a dispatcher sends them in the background,
+// sending them again on later runs if the retries of the client ran out, even after a restart of the process.
+// Deliveries that keep failing are dead-lettered, to be inspected and redriven by hand.
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/motain/httpeeve"
+)
+
+// Delivery is a request persisted by a Queue, along with how sending it went so far.
+type Delivery struct {
+	ID       string      `json:"id"`
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Header   http.Header `json:"header,omitempty"`
+	Body     []byte      `json:"body,omitempty"`
+	Enqueued time.Time   `json:"enqueued"`
+
+	// Runs is the number of times the dispatcher sent the request with Do, each of them made of as many attempts
+	// as the client allowed.
+	Runs int `json:"runs,omitempty"`
+	// NextRun is when the dispatcher is to send the request next.
+	NextRun time.Time `json:"next_run"`
+	// LastError is the message of the error of the last run, and LastStatusCode the status code of its response,
+	// or 0 if it got none.
+	LastError      string `json:"last_error,omitempty"`
+	LastStatusCode int    `json:"last_status_code,omitempty"`
+	// DeadLettered tells that the dispatcher gave up on the delivery, see Queue.Redrive.
+	DeadLettered bool `json:"dead_lettered,omitempty"`
+}
+
+// Request returns the request of the delivery, with ctx.
+func (d Delivery) Request(ctx context.Context) (*http.Request, error) {
+	var body io.Reader
+	if d.Body != nil {
+		body = bytes.NewReader(d.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, d.Method, d.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = d.Header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	return req, nil
+}
+
+func (d Delivery) clone() Delivery {
+	d.Header = d.Header.Clone()
+	if d.Body != nil {
+		d.Body = append([]byte(nil), d.Body...)
+	}
+	return d
+}
+
+const (
+	// DefaultMaxRuns is the number of runs after which a Queue dead-letters a delivery, unless WithRedelivery
+	// sets another one.
+	DefaultMaxRuns = 5
+	// DefaultRedeliveryDelay is the delay before the second run of a delivery, unless WithRedelivery sets
+	// another one.
+	DefaultRedeliveryDelay = time.Minute
+	// MaxRedeliveryDelay is how long the doubling delay between runs of a delivery grows at most.
+	MaxRedeliveryDelay = 24 * time.Hour
+	// DefaultPollInterval is how often Run looks for due deliveries, unless WithPollInterval sets another one.
+	DefaultPollInterval = time.Second
+)
+
+// Queue persists requests to a Store and sends them through an httpeeve client, see Enqueue and Run.
+type Queue struct {
+	client          httpeeve.Client
+	store           Store
+	maxRuns         int
+	redeliveryDelay time.Duration
+	pollInterval    time.Duration
+	now             func() time.Time
+
+	dispatching sync.Mutex
+	wake        chan struct{}
+}
+
+// Option configures a Queue.
+type Option func(*Queue)
+
+// WithRedelivery dead-letters deliveries after maxRuns runs, and waits delay before the second run of a
+// delivery, doubling it for every further run up to MaxRedeliveryDelay, or delay if it is longer. A run ends when
+// Do returns; with a BackoffClient, that is after as many attempts as its backoff allows.
+func WithRedelivery(maxRuns int, delay time.Duration) Option {
+	return func(q *Queue) {
+		q.maxRuns, q.redeliveryDelay = maxRuns, delay
+	}
+}
+
+// WithPollInterval makes Run look for due deliveries every interval, instead of DefaultPollInterval. Enqueue
+// and Redrive wake it up regardless.
+func WithPollInterval(interval time.Duration) Option {
+	return func(q *Queue) {
+		q.pollInterval = interval
+	}
+}
+
+// WithNow replaces how the Queue tells the time, which defaults to time.Now.
+func WithNow(now func() time.Time) Option {
+	return func(q *Queue) {
+		q.now = now
+	}
+}
+
+// New creates a Queue persisting deliveries to store, for instance a FileStore, and sending them with client,
+// usually an *httpeeve.BackoffClient whose backoff governs the retries within a run. Only deliveries whose
+// retries ran out, those failing with an *httpeeve.RetryError, and those the client turned down for the time
+// being, such as with httpeeve.ErrCircuitOpen, get another run: as a BackoffClient does not retry a POST by
+// default, configure it with httpeeve.WithIdempotencyKey, and set the key header on requests before enqueueing
+// them to keep the same key across runs.
+func New(client httpeeve.Client, store Store, opts ...Option) *Queue {
+	q := &Queue{
+		client:          client,
+		store:           store,
+		maxRuns:         DefaultMaxRuns,
+		redeliveryDelay: DefaultRedeliveryDelay,
+		pollInterval:    DefaultPollInterval,
+		now:             time.Now,
+		wake:            make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// Enqueue persists req, its body included, and returns the ID of its delivery. The request is sent by the
+// dispatcher, not by Enqueue; its context is not kept.
+func (q *Queue) Enqueue(req *http.Request) (string, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return "", fmt.Errorf("reading request body: %w", err)
+	}
+	id, err := newID()
+	if err != nil {
+		return "", fmt.Errorf("generating delivery ID: %w", err)
+	}
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	now := q.now()
+	delivery := Delivery{
+		ID:       id,
+		Method:   method,
+		URL:      req.URL.String(),
+		Header:   req.Header.Clone(),
+		Body:     body,
+		Enqueued: now,
+		NextRun:  now,
+	}
+	if err := q.store.Save(delivery); err != nil {
+		return "", err
+	}
+	q.notify()
+	return id, nil
+}
+
+// Run dispatches due deliveries until ctx is done, and returns the error of ctx, or the first error of the
+// Store. Run it in a goroutine of its own, once per Queue.
+func (q *Queue) Run(ctx context.Context) error {
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := q.DispatchDue(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// DispatchDue sends the deliveries that are due, oldest first, and returns how many of them were delivered. A
+// delivery that fails is sent again in a later run, or dead-lettered once it ran out of runs or failed with an
+// unretriable error. Deliveries interrupted by ctx are left as they were.
+func (q *Queue) DispatchDue(ctx context.Context) (int, error) {
+	q.dispatching.Lock()
+	defer q.dispatching.Unlock()
+
+	deliveries, err := q.store.List()
+	if err != nil {
+		return 0, err
+	}
+	now := q.now()
+	due := deliveries[:0]
+	for _, delivery := range deliveries {
+		if !delivery.DeadLettered && !delivery.NextRun.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextRun.Equal(due[j].NextRun) {
+			return due[i].NextRun.Before(due[j].NextRun)
+		}
+		return due[i].Enqueued.Before(due[j].Enqueued)
+	})
+
+	var delivered int
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return delivered, nil
+		}
+		ok, err := q.dispatch(ctx, delivery)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// dispatch sends delivery and removes it from the Store if it was delivered, or records how it failed.
+func (q *Queue) dispatch(ctx context.Context, delivery Delivery) (bool, error) {
+	req, err := delivery.Request(ctx)
+	if err != nil {
+		delivery.Runs++
+		return false, q.deadLetter(delivery, err, 0)
+	}
+
+	resp, err := q.client.Do(req)
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+		if resp.Body != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+	if err == nil {
+		return true, q.store.Delete(delivery.ID)
+	}
+	if ctx.Err() != nil {
+		return false, nil
+	}
+
+	delivery.Runs++
+	if !redeliverable(err) || delivery.Runs >= q.maxRuns {
+		return false, q.deadLetter(delivery, err, statusCode)
+	}
+	delivery.LastError, delivery.LastStatusCode = err.Error(), statusCode
+	delivery.NextRun = q.now().Add(q.delayAfter(delivery.Runs))
+	return false, q.store.Save(delivery)
+}
+
+// delayAfter returns the delay before the next run of a delivery that failed runs times.
+func (q *Queue) delayAfter(runs int) time.Duration {
+	delay := q.redeliveryDelay
+	for run := 1; run < runs && delay < MaxRedeliveryDelay; run++ {
+		delay *= 2
+	}
+	if delay > MaxRedeliveryDelay && q.redeliveryDelay < MaxRedeliveryDelay {
+		delay = MaxRedeliveryDelay
+	}
+	return delay
+}
+
+// redeliverable tells whether a delivery that failed with err may succeed in a later run: the retries of the
+// client ran out, or the client turned the request down for the time being.
+func redeliverable(err error) bool {
+	var retryErr *httpeeve.RetryError
+	return errors.As(err, &retryErr) ||
+		errors.Is(err, httpeeve.ErrCircuitOpen) ||
+		errors.Is(err, httpeeve.ErrQueueFull) ||
+		errors.Is(err, httpeeve.ErrQueueTimeout) ||
+		errors.Is(err, httpeeve.ErrRetryBudgetExhausted)
+}
+
+// deadLetter records that delivery failed with err for good.
+func (q *Queue) deadLetter(delivery Delivery, err error, statusCode int) error {
+	delivery.LastError, delivery.LastStatusCode = err.Error(), statusCode
+	delivery.DeadLettered = true
+	return q.store.Save(delivery)
+}
+
+// DeadLetters returns the deliveries the dispatcher gave up on, oldest first.
+func (q *Queue) DeadLetters() ([]Delivery, error) {
+	deliveries, err := q.store.List()
+	if err != nil {
+		return nil, err
+	}
+	deadLetters := deliveries[:0]
+	for _, delivery := range deliveries {
+		if delivery.DeadLettered {
+			deadLetters = append(deadLetters, delivery)
+		}
+	}
+	sort.Slice(deadLetters, func(i, j int) bool {
+		return deadLetters[i].Enqueued.Before(deadLetters[j].Enqueued)
+	})
+	return deadLetters, nil
+}
+
+// ErrNotDeadLettered is returned by Redrive for a delivery that does not exist or was not dead-lettered.
+var ErrNotDeadLettered = errors.New("delivery is not dead-lettered")
+
+// Redrive queues the dead-lettered delivery with id again, to be sent right away with all of its runs ahead.
+func (q *Queue) Redrive(id string) error {
+	deliveries, err := q.store.List()
+	if err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		if delivery.ID != id || !delivery.DeadLettered {
+			continue
+		}
+		delivery.DeadLettered = false
+		delivery.Runs = 0
+		delivery.NextRun = q.now()
+		if err := q.store.Save(delivery); err != nil {
+			return err
+		}
+		q.notify()
+		return nil
+	}
+	return ErrNotDeadLettered
+}
+
+// notify wakes up Run, unless it is about to wake up anyway.
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// requestBody reads the body of req, with GetBody if it has one so that req keeps its body.
+func requestBody(req *http.Request) ([]byte, error) {
+	body := req.Body
+	if req.GetBody != nil {
+		var err error
+		if body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	if body == nil || body == http.NoBody {
+		return nil, nil
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// newID returns a random ID of 16 bytes, hex encoded.
+func newID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", id), nil
+}
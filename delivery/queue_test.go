@@ -0,0 +1,219 @@
+package delivery
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/motain/httpeeve"
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Date(2019, time.May, 2, 10, 0, 0, 0, time.UTC)
+
+// clock is a time that tests move forward by hand.
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func retrying() *httpeeve.BackoffClient {
+	return httpeeve.NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), httpeeve.RetryOnStatusRange(500, 599),
+		httpeeve.WithIdempotencyKey("Idempotency-Key"))
+}
+
+func enqueue(t *testing.T, q *Queue, url, body string) string {
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	id, err := q.Enqueue(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return id
+}
+
+func TestDeliveredRequestsAreRemoved(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		received = append(received, req.Method+" "+req.Header.Get("Content-Type")+" "+string(body))
+	}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	q := New(retrying(), store)
+	enqueue(t, q, server.URL, `{"event":"created"}`)
+
+	delivered, err := q.DispatchDue(context.Background())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []string{`POST application/json {"event":"created"}`}, received)
+
+	deliveries, _ := store.List()
+	assert.Empty(t, deliveries)
+}
+
+func TestFailedDeliveriesAreRedeliveredUntilTheyRunOutOfRuns(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	now := &clock{epoch}
+	q := New(retrying(), NewMemoryStore(), WithRedelivery(2, time.Minute), WithNow(now.Now))
+	id := enqueue(t, q, server.URL, "{}")
+
+	delivered, err := q.DispatchDue(context.Background())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 0, delivered)
+	assert.Equal(t, 3, requestCount)
+
+	// not due yet
+	now.now = now.now.Add(30 * time.Second)
+	_, _ = q.DispatchDue(context.Background())
+	assert.Equal(t, 3, requestCount)
+	deadLetters, _ := q.DeadLetters()
+	assert.Empty(t, deadLetters)
+
+	now.now = now.now.Add(30 * time.Second)
+	_, _ = q.DispatchDue(context.Background())
+	assert.Equal(t, 6, requestCount)
+
+	deadLetters, err = q.DeadLetters()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, deadLetters, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, id, deadLetters[0].ID)
+	assert.Equal(t, 2, deadLetters[0].Runs)
+	assert.Equal(t, http.StatusServiceUnavailable, deadLetters[0].LastStatusCode)
+	assert.Equal(t, "bad status code 503", deadLetters[0].LastError)
+}
+
+func TestRedeliveryDelayStopsDoublingAtMaxRedeliveryDelay(t *testing.T) {
+	q := New(retrying(), NewMemoryStore(), WithRedelivery(70, time.Minute))
+	assert.Equal(t, time.Minute, q.delayAfter(1))
+	assert.Equal(t, 2*time.Minute, q.delayAfter(2))
+	assert.Equal(t, MaxRedeliveryDelay, q.delayAfter(69), "the delay does not overflow")
+
+	q = New(retrying(), NewMemoryStore(), WithRedelivery(3, 48*time.Hour))
+	assert.Equal(t, 48*time.Hour, q.delayAfter(2), "a longer delay is kept as it is")
+}
+
+func TestUnretriableFailuresAreDeadLetteredRightAway(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	q := New(retrying(), NewMemoryStore())
+	enqueue(t, q, server.URL, "{}")
+
+	_, err := q.DispatchDue(context.Background())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	deadLetters, _ := q.DeadLetters()
+	if !assert.Len(t, deadLetters, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, deadLetters[0].Runs)
+	assert.Equal(t, http.StatusBadRequest, deadLetters[0].LastStatusCode)
+}
+
+func TestDeliveriesTurnedDownByAnOpenCircuitAreRedelivered(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	now := &clock{epoch}
+	client := httpeeve.NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), httpeeve.RetryOnStatusRange(500, 599),
+		httpeeve.WithIdempotencyKey("Idempotency-Key"), httpeeve.WithCircuitBreaker(1, time.Hour, 1))
+	q := New(client, NewMemoryStore(), WithRedelivery(3, time.Minute), WithNow(now.Now))
+	enqueue(t, q, server.URL, "{}")
+	enqueue(t, q, server.URL, "{}")
+
+	_, err := q.DispatchDue(context.Background())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, requestCount, "the circuit opens after the first attempt")
+
+	deadLetters, _ := q.DeadLetters()
+	assert.Empty(t, deadLetters)
+	deliveries, _ := q.store.List()
+	for _, delivery := range deliveries {
+		assert.Equal(t, 1, delivery.Runs)
+		assert.Equal(t, epoch.Add(time.Minute), delivery.NextRun)
+		assert.Contains(t, delivery.LastError, httpeeve.ErrCircuitOpen.Error())
+	}
+}
+
+func TestDeadLettersCanBeRedriven(t *testing.T) {
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	store := NewMemoryStore()
+	q := New(retrying(), store)
+	id := enqueue(t, q, server.URL, "{}")
+	_, _ = q.DispatchDue(context.Background())
+
+	assert.Equal(t, ErrNotDeadLettered, q.Redrive("unknown"))
+	status = http.StatusOK
+	if !assert.NoError(t, q.Redrive(id)) {
+		t.FailNow()
+	}
+	assert.Equal(t, ErrNotDeadLettered, q.Redrive(id))
+
+	delivered, err := q.DispatchDue(context.Background())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, delivered)
+	deliveries, _ := store.List()
+	assert.Empty(t, deliveries)
+}
+
+func TestRunDispatchesEnqueuedRequests(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		received <- string(body)
+	}))
+	defer server.Close()
+
+	q := New(retrying(), NewMemoryStore(), WithPollInterval(time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- q.Run(ctx) }()
+
+	enqueue(t, q, server.URL, "hello")
+	select {
+	case body := <-received:
+		assert.Equal(t, "hello", body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not dispatched")
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
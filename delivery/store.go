@@ -0,0 +1,168 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store persists the deliveries of a Queue. Implementations must be safe for concurrent use.
+type Store interface {
+	// Save stores delivery, replacing the one with the same ID if any.
+	Save(delivery Delivery) error
+	// Delete removes the delivery with id, and succeeds if there is none.
+	Delete(id string) error
+	// List returns all deliveries stored, in any order.
+	List() ([]Delivery, error)
+}
+
+// MemoryStore is a Store holding deliveries in memory. They do not survive restarts of the process, which makes
+// it fit for tests, or for deliveries that only need to outlive the retries of a single request.
+type MemoryStore struct {
+	mu         sync.Mutex
+	deliveries map[string]Delivery
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{deliveries: map[string]Delivery{}}
+}
+
+// Save stores a copy of delivery.
+func (s *MemoryStore) Save(delivery Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries[delivery.ID] = delivery.clone()
+	return nil
+}
+
+// Delete removes the delivery with id, if any.
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.deliveries, id)
+	return nil
+}
+
+// List returns copies of all deliveries stored.
+func (s *MemoryStore) List() ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deliveries := make([]Delivery, 0, len(s.deliveries))
+	for _, delivery := range s.deliveries {
+		deliveries = append(deliveries, delivery.clone())
+	}
+	return deliveries, nil
+}
+
+// FileStore is a Store keeping every delivery as a JSON file in a directory, so that deliveries survive restarts
+// of the process. Files are replaced atomically, a crash leaves either the old or the new version of a delivery.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// fileSuffix is the extension of the files of deliveries in a FileStore.
+const fileSuffix = ".json"
+
+// NewFileStore creates a FileStore keeping deliveries in dir, which is created if it does not exist. Deliveries
+// stored in dir before are picked up.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Save writes delivery to a temporary file and moves it in place of the file of the delivery.
+func (s *FileStore) Save(delivery Delivery) error {
+	path, err := s.path(delivery.ID)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Delete removes the file of the delivery with id, if any.
+func (s *FileStore) Delete(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List reads the files of all deliveries in the directory.
+func (s *FileStore) List() ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []Delivery
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), fileSuffix) {
+			continue
+		}
+		encoded, err := ioutil.ReadFile(filepath.Join(s.dir, file.Name()))
+		if err != nil {
+			return deliveries, err
+		}
+		var delivery Delivery
+		if err := json.Unmarshal(encoded, &delivery); err != nil {
+			return deliveries, fmt.Errorf("reading delivery %s: %w", file.Name(), err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// path returns the file of the delivery with id, which must not point outside of the directory.
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid delivery ID %q", id)
+	}
+	return filepath.Join(s.dir, id+fileSuffix), nil
+}
@@ -0,0 +1,88 @@
+package delivery
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStoreKeepsDeliveriesAcrossRestarts(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+	}))
+	defer server.Close()
+
+	dir := tempDir(t)
+	store, err := NewFileStore(dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	id := enqueue(t, New(retrying(), store), server.URL, "{}")
+
+	restarted, err := NewFileStore(dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	deliveries, err := restarted.List()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, deliveries, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, id, deliveries[0].ID)
+	assert.Equal(t, []byte("{}"), deliveries[0].Body)
+	assert.Equal(t, "application/json", deliveries[0].Header.Get("Content-Type"))
+
+	delivered, err := New(retrying(), restarted).DispatchDue(context.Background())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, 1, requestCount)
+	deliveries, _ = restarted.List()
+	assert.Empty(t, deliveries)
+}
+
+func TestFileStoreRejectsIDsOutsideOfItsDirectory(t *testing.T) {
+	store, err := NewFileStore(tempDir(t))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Error(t, store.Save(Delivery{ID: "../escape"}))
+	assert.Error(t, store.Delete(""))
+	assert.NoError(t, store.Delete("missing"))
+}
+
+func TestMemoryStoreCopiesDeliveries(t *testing.T) {
+	store := NewMemoryStore()
+	delivery := Delivery{ID: "a", Header: http.Header{"X": {"1"}}, Body: []byte("body")}
+	if !assert.NoError(t, store.Save(delivery)) {
+		t.FailNow()
+	}
+	delivery.Body[0] = 'B'
+	delivery.Header.Set("X", "2")
+
+	deliveries, _ := store.List()
+	if !assert.Len(t, deliveries, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "body", string(deliveries[0].Body))
+	assert.Equal(t, "1", deliveries[0].Header.Get("X"))
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "delivery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
@@ -0,0 +1,99 @@
+package httpeeve
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// BackOffDiagnostics describes the configuration of a backoff, as far as it can be told from its type.
+type BackOffDiagnostics struct {
+	// Type is the Go type of the innermost backoff, such as "*backoff.ExponentialBackOff".
+	Type string
+	// Known tells whether the type is known, and hence whether the other fields are meaningful.
+	Known bool
+	// Wrappers lists the types of the wrappers of this package around the backoff, from outermost to innermost.
+	Wrappers []string
+
+	InitialInterval     time.Duration
+	MaxInterval         time.Duration
+	Multiplier          float64
+	RandomizationFactor float64
+	MaxElapsedTime      time.Duration
+
+	// Schedule is the strategy of a backoff built from a Schedule, such as "exponential" or "fibonacci", and
+	// Jitter its jitter, which is "full", "equal", "decorrelated" or empty.
+	Schedule string
+	Jitter   string
+}
+
+// DiagnoseBackOff reports the configuration of the backoff created by factory, to let you verify it without
+// resorting to reflection. The backoffs of cenkalti/backoff, those built from a Schedule and the wrappers of this
+// package are understood; for other types only Type is filled in.
+func DiagnoseBackOff(factory func() backoff.BackOff) BackOffDiagnostics {
+	var diagnostics BackOffDiagnostics
+
+	b := factory()
+	for {
+		var inner backoff.BackOff
+		switch wrapper := b.(type) {
+		case *immediateFirstRetry:
+			inner = wrapper.BackOff
+		case *deadlineClampBackOff:
+			inner = wrapper.BackOff
+		case *suggestingBackOff:
+			inner = wrapper.BackOff
+		}
+		if inner == nil {
+			break
+		}
+		diagnostics.Wrappers = append(diagnostics.Wrappers, fmt.Sprintf("%T", b))
+		b = inner
+	}
+
+	diagnostics.Type, diagnostics.Known = fmt.Sprintf("%T", b), true
+	switch b := b.(type) {
+	case *backoff.ExponentialBackOff:
+		diagnostics.InitialInterval = b.InitialInterval
+		diagnostics.MaxInterval = b.MaxInterval
+		diagnostics.Multiplier = b.Multiplier
+		diagnostics.RandomizationFactor = b.RandomizationFactor
+		diagnostics.MaxElapsedTime = b.MaxElapsedTime
+	case *backoff.ConstantBackOff:
+		diagnostics.InitialInterval = b.Interval
+		diagnostics.MaxInterval = b.Interval
+		diagnostics.Multiplier = 1
+	case *backoff.ZeroBackOff:
+		diagnostics.Multiplier = 1
+	case *backoff.StopBackOff:
+	case *scheduleBackOff:
+		diagnostics.describeSchedule(b.schedule)
+	default:
+		diagnostics.Known = false
+	}
+
+	return diagnostics
+}
+
+// describeSchedule fills in the configuration of a backoff built from schedule. Only the waits of exponential and
+// constant schedules grow by a Multiplier, and only full and equal jitter amount to a RandomizationFactor.
+func (d *BackOffDiagnostics) describeSchedule(schedule Schedule) {
+	d.Schedule, d.Jitter = schedule.kind, schedule.jitter.String()
+	d.InitialInterval, d.MaxInterval = schedule.base, schedule.max
+	switch schedule.kind {
+	case "exponential":
+		d.Multiplier = 2
+	case "constant":
+		d.Multiplier = 1
+		if d.MaxInterval <= 0 || d.MaxInterval > schedule.base {
+			d.MaxInterval = schedule.base
+		}
+	}
+	switch schedule.jitter {
+	case fullJitter:
+		d.RandomizationFactor = 1
+	case equalJitter:
+		d.RandomizationFactor = 0.5
+	}
+}
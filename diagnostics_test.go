@@ -0,0 +1,59 @@
+package httpeeve
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnoseExponentialBackOff(t *testing.T) {
+	diagnostics := DiagnoseBackOff(func() backoff.BackOff {
+		b := backoff.NewExponentialBackOff()
+		b.InitialInterval = 200 * time.Millisecond
+		b.MaxElapsedTime = time.Minute
+		return ImmediateFirstRetry(b)
+	})
+
+	assert.Equal(t, BackOffDiagnostics{
+		Type:                "*backoff.ExponentialBackOff",
+		Known:               true,
+		Wrappers:            []string{"*httpeeve.immediateFirstRetry"},
+		InitialInterval:     200 * time.Millisecond,
+		MaxInterval:         backoff.DefaultMaxInterval,
+		Multiplier:          backoff.DefaultMultiplier,
+		RandomizationFactor: backoff.DefaultRandomizationFactor,
+		MaxElapsedTime:      time.Minute,
+	}, diagnostics)
+}
+
+func TestDiagnoseUnknownBackOff(t *testing.T) {
+	diagnostics := DiagnoseBackOff(func() backoff.BackOff {
+		return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3)
+	})
+
+	assert.False(t, diagnostics.Known)
+	assert.Equal(t, "*backoff.backOffTries", diagnostics.Type)
+}
+
+func TestDiagnoseSchedule(t *testing.T) {
+	diagnostics := DiagnoseBackOff(Exponential(100*time.Millisecond, 10*time.Second).WithFullJitter().BackOff)
+	assert.Equal(t, BackOffDiagnostics{
+		Type:                "*httpeeve.scheduleBackOff",
+		Known:               true,
+		InitialInterval:     100 * time.Millisecond,
+		MaxInterval:         10 * time.Second,
+		Multiplier:          2,
+		RandomizationFactor: 1,
+		Schedule:            "exponential",
+		Jitter:              "full",
+	}, diagnostics)
+
+	diagnostics = DiagnoseBackOff(Fibonacci(time.Second).WithMax(time.Minute).WithDecorrelatedJitter().BackOff)
+	assert.True(t, diagnostics.Known)
+	assert.Equal(t, "fibonacci", diagnostics.Schedule)
+	assert.Equal(t, "decorrelated", diagnostics.Jitter)
+	assert.Equal(t, time.Second, diagnostics.InitialInterval)
+	assert.Equal(t, time.Minute, diagnostics.MaxInterval)
+}
@@ -0,0 +1,45 @@
+package httpeeve
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// DivergenceHook is called when two successive attempts of a request got responses with the same status
+// code but different bodies. attempt is the number of the later attempt.
+type DivergenceHook func(req *http.Request, attempt int, previous, current []byte)
+
+// WithDivergenceHook makes the client compare the bodies of successive responses to a request and report
+// differences to hook. This helps to catch upstreams whose GETs are not idempotent. Bodies are buffered and
+// restored, so Conditioners and callers can still read them.
+func WithDivergenceHook(hook DivergenceHook) Option {
+	return func(c *BackoffClient) {
+		c.divergenceHook = hook
+	}
+}
+
+// divergenceObserver remembers the previous response of a request for its DivergenceHook.
+type divergenceObserver struct {
+	hook       DivergenceHook
+	statusCode int
+	body       []byte
+	seen       bool
+}
+
+func (o *divergenceObserver) observe(req *http.Request, attempt int, resp *http.Response) error {
+	if o.hook == nil {
+		return nil
+	}
+
+	body, err := peekBody(resp)
+	if err != nil {
+		return err
+	}
+
+	if o.seen && o.statusCode == resp.StatusCode && !bytes.Equal(o.body, body) {
+		o.hook(req, attempt, o.body, body)
+	}
+
+	o.seen, o.statusCode, o.body = true, resp.StatusCode, body
+	return nil
+}
@@ -0,0 +1,62 @@
+package httpeeve
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDivergenceHook(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		fmt.Fprintf(w, "version %d", requestCount)
+	}))
+	defer server.Close()
+
+	var attempts int
+	var divergences []string
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, func(resp *http.Response) (bool, error) {
+		attempts++
+		if attempts < 3 {
+			return RetriableError("again")
+		}
+		return OK()
+	}, WithDivergenceHook(func(req *http.Request, attempt int, previous, current []byte) {
+		divergences = append(divergences, fmt.Sprintf("%d: %s -> %s", attempt, previous, current))
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2: version 1 -> version 2", "3: version 2 -> version 3"}, divergences)
+}
+
+func TestDivergenceHookIgnoresDifferentStatusCodes(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("oops"))
+			return
+		}
+		w.Write([]byte("fine"))
+	}))
+	defer server.Close()
+
+	var divergences int
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithDivergenceHook(func(*http.Request, int, []byte, []byte) {
+		divergences++
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Equal(t, 0, divergences)
+}
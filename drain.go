@@ -0,0 +1,53 @@
+package httpeeve
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+type contextKeyDrainedBytes struct{}
+
+// DrainedBytes tells how many bytes of response bodies were read and discarded while retrying the request
+// that resulted in resp. The body of resp itself is never drained. This is useful for bandwidth accounting.
+func DrainedBytes(resp *http.Response) int64 {
+	drained, _ := valueOf(resp, contextKeyDrainedBytes{}).(int64)
+	return drained
+}
+
+// WithMaxDrain caps how many bytes of a discarded response body the client reads before closing it. Draining a
+// body to its end lets its connection be reused, which is worth it for the usual small error pages, but not for
+// huge or endless bodies: once maxBytes are drained, the body is closed as it is, which closes its connection.
+// Bodies are drained without limit by default.
+func WithMaxDrain(maxBytes int64) Option {
+	return func(c *BackoffClient) {
+		c.maxDrain = maxBytes
+	}
+}
+
+// drainBody reads a discarded response body to its end and closes it, so its connection can be reused.
+func drainBody(resp *http.Response) int64 {
+	return drainBodyUpTo(resp, 0)
+}
+
+// drainBodyUpTo is like drainBody, but reads at most maxBytes of the body unless maxBytes is 0.
+func drainBodyUpTo(resp *http.Response, maxBytes int64) int64 {
+	if resp == nil || resp.Body == nil {
+		return 0
+	}
+
+	var body io.Reader = resp.Body
+	if maxBytes > 0 {
+		body = io.LimitReader(body, maxBytes)
+	}
+	drained, _ := io.Copy(ioutil.Discard, body)
+	resp.Body.Close()
+	return drained
+}
+
+func addDrainedBytesToRequest(resp *http.Response, drained int64) {
+	if resp != nil && resp.Request != nil && resp.Request.Context() != nil {
+		resp.Request = resp.Request.WithContext(context.WithValue(resp.Request.Context(), contextKeyDrainedBytes{}, drained))
+	}
+}
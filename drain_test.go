@@ -0,0 +1,117 @@
+package httpeeve
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainedBytes(t *testing.T) {
+	bodies := []string{"oops", "broken!!", "fine"}
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte(bodies[requestCount-1]))
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("oops")+len("broken!!")), DrainedBytes(resp))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "fine", string(body))
+}
+
+type closeCountingBody struct {
+	io.ReadCloser
+	closed *int
+}
+
+func (b closeCountingBody) Close() error {
+	*b.closed++
+	return b.ReadCloser.Close()
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestDiscardedBodiesAreClosed(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	closed := make([]int, 3)
+	var responses int
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err == nil {
+			resp.Body = closeCountingBody{ReadCloser: resp.Body, closed: &closed[responses]}
+			responses++
+		}
+		return resp, err
+	})
+
+	client := NewBackoffClient(http.Client{Transport: transport}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 1, 0}, closed, "only the discarded responses are closed")
+
+	resp.Body.Close()
+	assert.Equal(t, []int{1, 1, 1}, closed)
+}
+
+func TestMaxDrain(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(make([]byte, 1<<20))
+			return
+		}
+		w.Write([]byte("fine"))
+	}))
+	defer server.Close()
+
+	var closed int
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err == nil {
+			resp.Body = closeCountingBody{ReadCloser: resp.Body, closed: &closed}
+		}
+		return resp, err
+	})
+
+	client := NewBackoffClient(http.Client{Transport: transport}, &backoff.ZeroBackOff{}, retryOn5XX, WithMaxDrain(1024))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), DrainedBytes(resp))
+	assert.Equal(t, 1, closed, "the huge body is closed without being drained in full")
+	resp.Body.Close()
+}
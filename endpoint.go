@@ -0,0 +1,198 @@
+package httpeeve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// Endpoint is where an attempt connects to, in place of what the "net/http".Client would pick by itself.
+type Endpoint struct {
+	// Addr is the host, or host and port, to dial instead of the host of the request URL, for instance one of the
+	// IP addresses the host resolves to. It applies to connections that do not go through a proxy. An empty Addr
+	// dials the host of the request URL; without a port, the port of the request URL is used.
+	Addr string
+	// Proxy is the proxy to connect through instead of the one of the Transport, or nil for the latter.
+	Proxy *url.URL
+}
+
+// EndpointSelector picks the endpoint for the next attempt at req, given the endpoints of the earlier attempts
+// that failed to connect, in order. Its error fails the attempt, which is retried if the error is, for instance
+// a temporary DNS failure.
+type EndpointSelector func(req *http.Request, failed []Endpoint) (Endpoint, error)
+
+// WithEndpointRotation lets every attempt of a request connect to the endpoint picked by selector, so that a
+// request that failed to connect, for instance to a dead IP address behind a hostname with several A
+// records, or through a broken proxy, is retried elsewhere rather than against the same endpoint. Errors of
+// failed connections are then retried, and so are DNS failures and refused connections as before. See
+// ResolvedAddresses and Proxies. This only works if the Transport of the "net/http".Client is an
+// *http.Transport, or nil.
+func WithEndpointRotation(selector EndpointSelector) Option {
+	return func(c *BackoffClient) {
+		c.endpointSelector = selector
+	}
+}
+
+// ResolvedAddresses is an EndpointSelector that dials the addresses resolver, or the default resolver if it
+// is nil, resolves the host of the request to, starting with the first and moving on to the next that did
+// not fail yet. Once all of them failed, it starts over.
+func ResolvedAddresses(resolver *net.Resolver) EndpointSelector {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return func(req *http.Request, failed []Endpoint) (Endpoint, error) {
+		addrs, err := resolver.LookupHost(req.Context(), req.URL.Hostname())
+		if err != nil {
+			return Endpoint{}, err
+		}
+
+		failedAddrs := make([]string, len(failed))
+		for i, endpoint := range failed {
+			failedAddrs[i] = endpoint.Addr
+		}
+		return Endpoint{Addr: addrs[rotate(addrs, failedAddrs)]}, nil
+	}
+}
+
+// Proxies is an EndpointSelector that connects through proxies, starting with the first and moving on to the
+// next that did not fail yet. Once all of them failed, it starts over.
+func Proxies(proxies ...*url.URL) EndpointSelector {
+	candidates := make([]string, len(proxies))
+	for i, proxy := range proxies {
+		candidates[i] = proxy.String()
+	}
+	return func(req *http.Request, failed []Endpoint) (Endpoint, error) {
+		if len(proxies) == 0 {
+			return Endpoint{}, nil
+		}
+
+		failedProxies := make([]string, 0, len(failed))
+		for _, endpoint := range failed {
+			if endpoint.Proxy != nil {
+				failedProxies = append(failedProxies, endpoint.Proxy.String())
+			}
+		}
+		return Endpoint{Proxy: proxies[rotate(candidates, failedProxies)]}, nil
+	}
+}
+
+// rotate returns the index of the first candidate that is not among failed, or, if all of them are, of the
+// one whose turn it is to be tried again.
+func rotate(candidates []string, failed []string) int {
+	isFailed := make(map[string]bool, len(failed))
+	for _, candidate := range failed {
+		isFailed[candidate] = true
+	}
+	for i, candidate := range candidates {
+		if !isFailed[candidate] {
+			return i
+		}
+	}
+	return len(failed) % len(candidates)
+}
+
+type contextKeyEndpoint struct{}
+
+// endpointDial is the endpoint of an attempt, along with the address the attempt would dial without it.
+type endpointDial struct {
+	target   string
+	endpoint Endpoint
+}
+
+// selectEndpoint picks the endpoint of the attempt at req, and returns req with the endpoint in its context.
+func (c *call) selectEndpoint(req *http.Request) (*http.Request, error) {
+	endpoint, err := c.client.endpointSelector(req, c.failedEndpoints)
+	if err != nil {
+		return nil, fmt.Errorf("selecting endpoint: %w", err)
+	}
+	c.endpoint = endpoint
+
+	target := canonicalAddr(req.URL)
+	return req.WithContext(context.WithValue(req.Context(), contextKeyEndpoint{}, endpointDial{target: target, endpoint: endpoint})), nil
+}
+
+// endpointFailed tells whether reqErr means that the attempt could not connect to its endpoint, and remembers
+// the endpoint as failed if it does.
+func (c *call) endpointFailed(reqErr error) bool {
+	var (
+		dnsErr *net.DNSError
+		opErr  *net.OpError
+	)
+	if !errors.As(reqErr, &dnsErr) && !(errors.As(reqErr, &opErr) && (opErr.Op == "dial" || opErr.Op == "proxyconnect")) {
+		return false
+	}
+
+	c.failedEndpoints = append(c.failedEndpoints, c.endpoint)
+	return dnsErr == nil
+}
+
+// endpointClient returns a copy of httpClient whose Transport connects to the endpoints of the attempts, or
+// httpClient itself if its Transport cannot be tweaked. The copy of a Transport is created once, so that its
+// connections are reused.
+func (c *BackoffClient) endpointClient(httpClient *http.Client) *http.Client {
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if httpClient.Transport == nil {
+		transport, ok = http.DefaultTransport.(*http.Transport)
+	}
+	if !ok {
+		return httpClient
+	}
+
+	rotating, ok := c.endpointTransports.Load(transport)
+	if !ok {
+		rotating, _ = c.endpointTransports.LoadOrStore(transport, rotatingTransport(transport))
+	}
+
+	client := *httpClient
+	client.Transport = rotating.(*http.Transport)
+	return &client
+}
+
+// rotatingTransport returns a copy of transport that dials, and proxies through, the endpoint in the context
+// of a request.
+func rotatingTransport(transport *http.Transport) *http.Transport {
+	rotating := transport.Clone()
+
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	rotating.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if dialing, ok := ctx.Value(contextKeyEndpoint{}).(endpointDial); ok && dialing.endpoint.Addr != "" && addr == dialing.target {
+			addr = dialing.endpoint.Addr
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				_, port, _ := net.SplitHostPort(dialing.target)
+				addr = net.JoinHostPort(addr, port)
+			}
+		}
+		return dial(ctx, network, addr)
+	}
+
+	proxy := transport.Proxy
+	rotating.Proxy = func(req *http.Request) (*url.URL, error) {
+		if dialing, ok := req.Context().Value(contextKeyEndpoint{}).(endpointDial); ok && dialing.endpoint.Proxy != nil {
+			return dialing.endpoint.Proxy, nil
+		}
+		if proxy == nil {
+			return nil, nil
+		}
+		return proxy(req)
+	}
+
+	return rotating
+}
+
+// canonicalAddr returns the host and port u is dialed at, like "net/http" does.
+func canonicalAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
@@ -0,0 +1,108 @@
+package httpeeve
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+// deadAddr returns an address nothing listens at.
+func deadAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func TestEndpointRotationMovesOnFromDeadAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dead := deadAddr(t)
+	var seen [][]Endpoint
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3), retryOn5XX,
+		WithEndpointRotation(func(req *http.Request, failed []Endpoint) (Endpoint, error) {
+			seen = append(seen, append([]Endpoint(nil), failed...))
+			if len(failed) == 0 {
+				return Endpoint{Addr: dead}, nil
+			}
+			return Endpoint{Addr: server.Listener.Addr().String()}, nil
+		}))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://service.invalid/", nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Equal(t, [][]Endpoint{nil, {{Addr: dead}}}, seen)
+}
+
+func TestEndpointRotationThroughProxies(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied = append(proxied, req.URL.String())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	deadProxy, _ := url.Parse("http://" + deadAddr(t))
+	liveProxy, _ := url.Parse(proxy.URL)
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3), retryOn5XX,
+		WithEndpointRotation(Proxies(deadProxy, liveProxy)))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://service.invalid/path", nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Equal(t, []string{"http://service.invalid/path"}, proxied)
+}
+
+func TestEndpointRotationFailsOnSelectorErrors(t *testing.T) {
+	var selections int
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3), retryOn5XX,
+		WithEndpointRotation(func(req *http.Request, failed []Endpoint) (Endpoint, error) {
+			selections++
+			if selections == 1 {
+				return Endpoint{}, &net.DNSError{Err: "server misbehaving", Name: "service.invalid", IsTemporary: true}
+			}
+			return Endpoint{}, &net.DNSError{Err: "no such host", Name: "service.invalid", IsNotFound: true}
+		}))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://service.invalid/", nil)
+	_, err := client.Do(req)
+	assert.EqualError(t, err, "selecting endpoint: lookup service.invalid: no such host")
+	var dnsErr *net.DNSError
+	assert.True(t, errors.As(err, &dnsErr))
+	assert.Equal(t, 2, selections)
+}
+
+func TestResolvedAddressesRotates(t *testing.T) {
+	selector := ResolvedAddresses(nil)
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:8080/", nil)
+
+	endpoint, err := selector(req, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Endpoint{Addr: "127.0.0.1"}, endpoint)
+
+	endpoint, err = selector(req, []Endpoint{{Addr: "127.0.0.1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, Endpoint{Addr: "127.0.0.1"}, endpoint, "it starts over once all addresses failed")
+}
+
+func TestRotate(t *testing.T) {
+	candidates := []string{"a", "b", "c"}
+	assert.Equal(t, 0, rotate(candidates, nil))
+	assert.Equal(t, 2, rotate(candidates, []string{"a", "b"}))
+	assert.Equal(t, 0, rotate(candidates, []string{"a", "b", "c"}))
+	assert.Equal(t, 1, rotate(candidates, []string{"a", "b", "c", "a"}))
+}
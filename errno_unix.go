@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package httpeeve
+
+import "syscall"
+
+// errnoAliases returns the errnos the platform reports for errno. On Unix these are the errnos themselves.
+func errnoAliases(errno syscall.Errno) []syscall.Errno {
+	return []syscall.Errno{errno}
+}
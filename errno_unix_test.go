@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package httpeeve
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetriableSyscallErrorsOnUnix(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithRetriableSyscallErrors(syscall.EHOSTUNREACH, syscall.ENETUNREACH))
+
+	for _, errno := range []syscall.Errno{syscall.EHOSTUNREACH, syscall.ENETUNREACH} {
+		err := &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", errno)}
+		assert.Equal(t, err, client.categorizeRequestError(testRequest, err), errno.Error())
+	}
+}
@@ -0,0 +1,35 @@
+//go:build windows
+// +build windows
+
+package httpeeve
+
+import "syscall"
+
+// Winsock error codes that have no constant in the syscall package.
+const (
+	wsaeshutdown     syscall.Errno = 10058
+	wsaeconnrefused  syscall.Errno = 10061
+	wsaehostunreach  syscall.Errno = 10065
+	wsaenetunreach   syscall.Errno = 10051
+	wsaetimedout     syscall.Errno = 10060
+	wsaeaddrnotavail syscall.Errno = 10049
+)
+
+// windowsErrnos maps the POSIX errnos of the syscall package, which are invented on Windows and never
+// returned by the network stack, to the Winsock and Win32 codes that are reported instead.
+var windowsErrnos = map[syscall.Errno][]syscall.Errno{
+	syscall.ECONNRESET:    {syscall.WSAECONNRESET},
+	syscall.ECONNABORTED:  {syscall.WSAECONNABORTED},
+	syscall.ECONNREFUSED:  {wsaeconnrefused},
+	syscall.EPIPE:         {syscall.ERROR_BROKEN_PIPE, wsaeshutdown},
+	syscall.EHOSTUNREACH:  {wsaehostunreach},
+	syscall.ENETUNREACH:   {wsaenetunreach},
+	syscall.ETIMEDOUT:     {wsaetimedout},
+	syscall.EADDRNOTAVAIL: {wsaeaddrnotavail},
+}
+
+// errnoAliases returns the errnos the platform reports for errno, so that users can declare
+// syscall.ECONNREFUSED as retriable regardless of the operating system.
+func errnoAliases(errno syscall.Errno) []syscall.Errno {
+	return append([]syscall.Errno{errno}, windowsErrnos[errno]...)
+}
@@ -0,0 +1,66 @@
+package httpeeve
+
+import (
+	"net/http"
+	"sync"
+)
+
+// AdaptiveRetry wraps conditioner to avoid retrying into a widespread outage. It keeps track of whether the
+// last windowSize responses were judged erroneous by conditioner. While the window is full and its error rate
+// is at least highErrorRate, errors are returned right away instead of being retried, which sheds load from
+// the upstream. Retrying resumes once enough responses succeed again. Failures without a response are not
+// counted, as they never reach a Conditioner.
+//
+// The returned Conditioner keeps its state across requests, so share it between all requests to the same
+// upstream.
+func AdaptiveRetry(windowSize int, highErrorRate float64, conditioner Conditioner) Conditioner {
+	window := &errorWindow{outcomes: make([]bool, windowSize)}
+
+	return func(resp *http.Response) (bool, error) {
+		shouldRetry, err := conditioner(resp)
+		if err == errAbstain {
+			return shouldRetry, err
+		}
+
+		if rate, full := window.record(err != nil); shouldRetry && full && rate >= highErrorRate {
+			return false, err
+		}
+
+		return shouldRetry, err
+	}
+}
+
+// errorWindow is a ring buffer of the most recent outcomes, true meaning an error.
+type errorWindow struct {
+	mu       sync.Mutex
+	outcomes []bool
+	next     int
+	count    int
+	errors   int
+}
+
+// record adds an outcome and returns the error rate of the window and whether the window is full.
+func (w *errorWindow) record(failed bool) (float64, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.outcomes) == 0 {
+		return 0, false
+	}
+
+	if w.count == len(w.outcomes) {
+		if w.outcomes[w.next] {
+			w.errors--
+		}
+	} else {
+		w.count++
+	}
+
+	w.outcomes[w.next] = failed
+	if failed {
+		w.errors++
+	}
+	w.next = (w.next + 1) % len(w.outcomes)
+
+	return float64(w.errors) / float64(w.count), w.count == len(w.outcomes)
+}
@@ -0,0 +1,35 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveRetry(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), AdaptiveRetry(4, 0.75, retryOn5XX))
+	get := func() int {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+		resp, _ := client.Do(req)
+		return Attempts(resp)
+	}
+
+	assert.Equal(t, 3, get(), "retried while the window fills up")
+	assert.Equal(t, 1, get(), "the burst of errors disables retries")
+
+	status = http.StatusOK
+	assert.Equal(t, 1, get())
+	assert.Equal(t, 1, get())
+
+	status = http.StatusServiceUnavailable
+	assert.Equal(t, 3, get(), "retries resume once the error rate recovers")
+}
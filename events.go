@@ -0,0 +1,72 @@
+package httpeeve
+
+import (
+	"net/http"
+	"time"
+)
+
+// RetryEventType tells which stage of a request a RetryEvent reports on.
+type RetryEventType int
+
+const (
+	// AttemptStarted is published right before an attempt is sent.
+	AttemptStarted RetryEventType = iota
+	// Retrying is published when an attempt failed and another one has been scheduled.
+	Retrying
+	// Succeeded is published when a response has been accepted by the Conditioner.
+	Succeeded
+	// Exhausted is published when the client gives up on a request and returns an error.
+	Exhausted
+)
+
+func (t RetryEventType) String() string {
+	switch t {
+	case AttemptStarted:
+		return "attempt started"
+	case Retrying:
+		return "retrying"
+	case Succeeded:
+		return "succeeded"
+	case Exhausted:
+		return "exhausted"
+	default:
+		return "unknown"
+	}
+}
+
+// RetryEvent describes a single step in the life of a request sent through a BackoffClient.
+type RetryEvent struct {
+	Type    RetryEventType
+	Request *http.Request
+	// Attempt is the number of the attempt the event refers to, starting at 1.
+	Attempt int
+	// Delay is the time until the next attempt. It is only set for Retrying events.
+	Delay time.Duration
+	// Err is the error that caused a Retrying or Exhausted event.
+	Err error
+}
+
+// WithEvents makes the client publish RetryEvents on the channel returned by Events, buffering up to
+// bufferSize of them. Events are published without blocking: when the buffer is full they are dropped.
+func WithEvents(bufferSize int) Option {
+	return func(c *BackoffClient) {
+		c.events = make(chan RetryEvent, bufferSize)
+	}
+}
+
+// Events returns the channel RetryEvents are published on. It is nil unless the client was created
+// with WithEvents.
+func (c *BackoffClient) Events() <-chan RetryEvent {
+	return c.events
+}
+
+func (c *BackoffClient) publish(event RetryEvent) {
+	if c.events == nil {
+		return
+	}
+
+	select {
+	case c.events <- event:
+	default:
+	}
+}
@@ -0,0 +1,63 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventsForMultiAttemptRequest(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, func(resp *http.Response) (bool, error) {
+		if resp.StatusCode == 500 {
+			return RetriableError("bad")
+		}
+		return OK()
+	}, WithEvents(10))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+
+	var types []RetryEventType
+	var attempts []int
+	for len(client.Events()) > 0 {
+		event := <-client.Events()
+		types = append(types, event.Type)
+		attempts = append(attempts, event.Attempt)
+	}
+
+	assert.Equal(t, []RetryEventType{AttemptStarted, Retrying, AttemptStarted, Retrying, AttemptStarted, Succeeded}, types)
+	assert.Equal(t, []int{1, 1, 2, 2, 3, 3}, attempts)
+}
+
+func TestEventsAreDroppedWhenBufferIsFull(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, func(resp *http.Response) (bool, error) {
+		return PermanentError("bad")
+	}, WithEvents(1))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.EqualError(t, err, "bad")
+
+	assert.Equal(t, AttemptStarted, (<-client.Events()).Type)
+	assert.Len(t, client.Events(), 0)
+}
@@ -0,0 +1,291 @@
+package httpeeve
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RetryOnExpression returns a Conditioner that retries responses matching expr, a small expression over the
+// status code and the headers of a response, and judges all others by conditioner. It lets policies be
+// configured without recompiling, for instance:
+//
+//	status in (502, 503) or header[X-Retry] == '1'
+//
+// The status can be compared with ==, !=, <, <=, > and >= to a number, or tested for membership of a
+// list with in. A header, named in brackets, can be compared with == and != to a quoted string; a missing
+// header equals the empty string. Comparisons are combined with and, or, not and parentheses. An error is returned if
+// expr cannot be parsed.
+func RetryOnExpression(expr string, conditioner Conditioner) (Conditioner, error) {
+	tokens, err := lexExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &expressionParser{tokens: tokens}
+	matches, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in expression", p.tokens[p.pos].text)
+	}
+
+	return func(resp *http.Response) (bool, error) {
+		if matches(resp) {
+			return RetriableErrorf("response matches %q (status code %d)", expr, resp.StatusCode)
+		}
+		return conditioner(resp)
+	}, nil
+}
+
+type (
+	expressionToken struct {
+		kind byte // 'i' identifier, 'n' number, 's' string, 'o' operator or punctuation
+		text string
+	}
+
+	expressionParser struct {
+		tokens []expressionToken
+		pos    int
+	}
+
+	predicate func(resp *http.Response) bool
+)
+
+func lexExpression(expr string) ([]expressionToken, error) {
+	var tokens []expressionToken
+	for i := 0; i < len(expr); {
+		r := rune(expr[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsLetter(r):
+			j := i
+			for j < len(expr) && (isLetterOrDigit(expr[j]) || expr[j] == '-' || expr[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, expressionToken{'i', expr[i:j]})
+			i = j
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(expr) && unicode.IsDigit(rune(expr[j])) {
+				j++
+			}
+			tokens = append(tokens, expressionToken{'n', expr[i:j]})
+			i = j
+		case r == '\'' || r == '"':
+			j := strings.IndexByte(expr[i+1:], expr[i])
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated string in expression at offset %d", i)
+			}
+			tokens = append(tokens, expressionToken{'s', expr[i+1 : i+1+j]})
+			i += j + 2
+		case strings.ContainsRune("()[],", r):
+			tokens = append(tokens, expressionToken{'o', expr[i : i+1]})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			j := i + 1
+			if j < len(expr) && expr[j] == '=' {
+				j++
+			}
+			if op := expr[i:j]; op == "=" || op == "!" {
+				return nil, fmt.Errorf("unknown operator %q in expression", op)
+			}
+			tokens = append(tokens, expressionToken{'o', expr[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q in expression", r)
+		}
+	}
+	return tokens, nil
+}
+
+func isLetterOrDigit(b byte) bool {
+	return unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b))
+}
+
+func (p *expressionParser) peek() expressionToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return expressionToken{}
+}
+
+func (p *expressionParser) accept(kind byte, text string) bool {
+	if token := p.peek(); token.kind == kind && strings.EqualFold(token.text, text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *expressionParser) expect(kind byte, text string) error {
+	if !p.accept(kind, text) {
+		return p.unexpected(fmt.Sprintf("%q", text))
+	}
+	return nil
+}
+
+func (p *expressionParser) next(kind byte, what string) (string, error) {
+	token := p.peek()
+	if token.kind != kind {
+		return "", p.unexpected(what)
+	}
+	p.pos++
+	return token.text, nil
+}
+
+func (p *expressionParser) unexpected(what string) error {
+	if p.pos >= len(p.tokens) {
+		return fmt.Errorf("expected %s at end of expression", what)
+	}
+	return fmt.Errorf("expected %s in expression, got %q", what, p.tokens[p.pos].text)
+}
+
+func (p *expressionParser) parseOr() (predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept('i', "or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(resp *http.Response) bool { return l(resp) || right(resp) }
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parseAnd() (predicate, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept('i', "and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(resp *http.Response) bool { return l(resp) && right(resp) }
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parseNot() (predicate, error) {
+	if p.accept('i', "not") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(resp *http.Response) bool { return !operand(resp) }, nil
+	}
+
+	if p.accept('o', "(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect('o', ")")
+	}
+
+	switch {
+	case p.accept('i', "status"):
+		return p.parseStatus()
+	case p.accept('i', "header"):
+		return p.parseHeader()
+	default:
+		return nil, p.unexpected("status, header, not or (")
+	}
+}
+
+func (p *expressionParser) parseStatus() (predicate, error) {
+	if p.accept('i', "in") {
+		if err := p.expect('o', "("); err != nil {
+			return nil, err
+		}
+		codes := map[int]bool{}
+		for {
+			code, err := p.parseNumber()
+			if err != nil {
+				return nil, err
+			}
+			codes[code] = true
+			if !p.accept('o', ",") {
+				break
+			}
+		}
+		if err := p.expect('o', ")"); err != nil {
+			return nil, err
+		}
+		return func(resp *http.Response) bool { return codes[resp.StatusCode] }, nil
+	}
+
+	op, err := p.next('o', "comparison")
+	if err != nil {
+		return nil, err
+	}
+	code, err := p.parseNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "==":
+		return func(resp *http.Response) bool { return resp.StatusCode == code }, nil
+	case "!=":
+		return func(resp *http.Response) bool { return resp.StatusCode != code }, nil
+	case "<":
+		return func(resp *http.Response) bool { return resp.StatusCode < code }, nil
+	case "<=":
+		return func(resp *http.Response) bool { return resp.StatusCode <= code }, nil
+	case ">":
+		return func(resp *http.Response) bool { return resp.StatusCode > code }, nil
+	case ">=":
+		return func(resp *http.Response) bool { return resp.StatusCode >= code }, nil
+	default:
+		return nil, fmt.Errorf("expected comparison in expression, got %q", op)
+	}
+}
+
+func (p *expressionParser) parseHeader() (predicate, error) {
+	if err := p.expect('o', "["); err != nil {
+		return nil, err
+	}
+	name := p.peek()
+	if name.kind != 'i' && name.kind != 's' {
+		return nil, p.unexpected("header name")
+	}
+	p.pos++
+	if err := p.expect('o', "]"); err != nil {
+		return nil, err
+	}
+
+	var equal bool
+	switch {
+	case p.accept('o', "=="):
+		equal = true
+	case p.accept('o', "!="):
+	default:
+		return nil, p.unexpected("== or !=")
+	}
+	value, err := p.next('s', "quoted string")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(resp *http.Response) bool { return (resp.Header.Get(name.text) == value) == equal }, nil
+}
+
+func (p *expressionParser) parseNumber() (int, error) {
+	text, err := p.next('n', "number")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(text)
+}
@@ -0,0 +1,59 @@
+package httpeeve
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryOnExpression(t *testing.T) {
+	for _, tc := range []struct {
+		expr   string
+		status int
+		header http.Header
+		retry  bool
+	}{
+		{"status in (502,503) or header[X-Retry]=='1'", 503, nil, true},
+		{"status in (502,503) or header[X-Retry]=='1'", 500, nil, false},
+		{"status in (502,503) or header[X-Retry]=='1'", 409, http.Header{"X-Retry": {"1"}}, true},
+		{"status >= 500 and not (status == 501)", 501, nil, false},
+		{"status >= 500 and not (status == 501)", 504, nil, true},
+		{`header["Retry-After"] != ''`, 429, http.Header{"Retry-After": {"3"}}, true},
+		{`header["Retry-After"] != ''`, 429, nil, false},
+	} {
+		conditioner, err := RetryOnExpression(tc.expr, func(resp *http.Response) (bool, error) { return OK() })
+		if !assert.NoError(t, err, tc.expr) {
+			continue
+		}
+
+		shouldRetry, _ := conditioner(&http.Response{StatusCode: tc.status, Header: tc.header})
+		assert.Equal(t, tc.retry, shouldRetry, "%s with status %d", tc.expr, tc.status)
+	}
+}
+
+func TestRetryOnExpressionError(t *testing.T) {
+	conditioner, err := RetryOnExpression("status == 503", retryOn5XX)
+	assert.NoError(t, err)
+
+	_, err = conditioner(&http.Response{StatusCode: 503})
+	assert.EqualError(t, err, `response matches "status == 503" (status code 503)`)
+}
+
+func TestRetryOnExpressionRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"status",
+		"status = 500",
+		"status in (500",
+		"header[X-Retry] > '1'",
+		"header[X-Retry] == 1",
+		"status == 500 or",
+		"status == 500 500",
+		"os.Exit(1)",
+		"header[X-Retry] == 'unterminated",
+	} {
+		_, err := RetryOnExpression(expr, retryOn5XX)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,47 @@
+package httpeeve
+
+import (
+	"github.com/cenkalti/backoff"
+)
+
+// WithBackoffFactory makes the client call factory for a fresh backoff for every request, instead of
+// replacing the exponential backoff of NewClient with a single one as WithBackoff does. Use it for backoffs
+// that keep state the client cannot copy, such as those of backoff.WithMaxRetries or of your own types, so
+// that concurrent requests do not share it.
+func WithBackoffFactory(factory func() backoff.BackOff) Option {
+	return func(c *BackoffClient) {
+		c.backoffer = nil
+		c.backoffFactory = factory
+	}
+}
+
+// newBackOff returns the backoff of the client for a single request.
+func (c *BackoffClient) newBackOff() backoff.BackOff {
+	if c.backoffFactory != nil {
+		return c.backoffFactory()
+	}
+	return cloneBackOff(c.backoffer)
+}
+
+// cloneBackOff returns a copy of b that can be used for a single request, without affecting b or the other
+// requests using it. The backoffs of cenkalti/backoff and the wrappers of this package are copied, with the
+// exception of backoff.WithMaxRetries; other backoffs are returned as they are, to be shared by all requests.
+func cloneBackOff(b backoff.BackOff) backoff.BackOff {
+	switch b := b.(type) {
+	case *backoff.ExponentialBackOff:
+		clone := *b
+		clone.Reset()
+		return &clone
+	case *scheduleBackOff:
+		clone := *b
+		clone.Reset()
+		return &clone
+	case *immediateFirstRetry:
+		return &immediateFirstRetry{BackOff: cloneBackOff(b.BackOff)}
+	case *deadlineClampBackOff:
+		return &deadlineClampBackOff{BackOff: cloneBackOff(b.BackOff), ctx: b.ctx, fraction: b.fraction, now: b.now}
+	default:
+		// stateless, such as *backoff.ConstantBackOff, or unknown
+		return b
+	}
+}
@@ -0,0 +1,96 @@
+package httpeeve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentRequestsDoNotShareTheBackoff(t *testing.T) {
+	var mu sync.Mutex
+	requestCounts := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requestCounts[req.URL.Path]++
+		if requestCounts[req.URL.Path] < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	exponential := backoff.NewExponentialBackOff()
+	exponential.InitialInterval = time.Millisecond
+	exponential.RandomizationFactor = 0
+	exponential.Reset()
+	var delaysMu sync.Mutex
+	var delays []time.Duration
+	client := NewBackoffClient(http.Client{}, exponential, retryOn5XX, WithSleepFunc(func(d time.Duration) {
+		delaysMu.Lock()
+		defer delaysMu.Unlock()
+		delays = append(delays, d)
+	}))
+
+	var wg sync.WaitGroup
+	for _, path := range []string{"/a", "/b", "/c", "/d"} {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, Attempts(resp))
+		}(path)
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, []time.Duration{
+		time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond,
+		1500 * time.Microsecond, 1500 * time.Microsecond, 1500 * time.Microsecond, 1500 * time.Microsecond,
+	}, delays)
+	assert.Equal(t, time.Millisecond, exponential.NextBackOff(), "the backoff of the client is left alone")
+}
+
+func TestWithBackoffFactory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var created int
+	client := NewClient(http.Client{}, retryOn5XX, WithBackoffFactory(func() backoff.BackOff {
+		created++
+		return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+	}))
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		assert.Error(t, err)
+		assert.Equal(t, 3, Attempts(resp), "every request gets retries of its own")
+	}
+	assert.Equal(t, 2, created)
+}
+
+func TestCloneBackOff(t *testing.T) {
+	exponential := backoff.NewExponentialBackOff()
+	exponential.RandomizationFactor = 0
+	exponential.Reset()
+	assert.Equal(t, 500*time.Millisecond, exponential.NextBackOff())
+	clamped := ClampToDeadline(context.Background(), ImmediateFirstRetry(exponential), 0.5)
+
+	clone := cloneBackOff(clamped)
+	assert.NotSame(t, clamped, clone)
+	assert.Equal(t, time.Duration(0), clone.NextBackOff())
+	assert.Equal(t, 500*time.Millisecond, clone.NextBackOff(), "the copy starts over")
+	assert.Equal(t, 750*time.Millisecond, exponential.NextBackOff(), "the original is left alone")
+
+	constant := backoff.NewConstantBackOff(time.Second)
+	assert.Same(t, constant, cloneBackOff(constant), "stateless backoffs are shared")
+}
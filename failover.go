@@ -0,0 +1,106 @@
+package httpeeve
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// FailoverClient sends requests to one of several equivalent endpoints, such as the same service in different
+// regions, failing over to the next one when a BackoffClient gives up on an endpoint, see NewFailoverClient.
+type FailoverClient struct {
+	client    *BackoffClient
+	endpoints []*url.URL
+	cooldown  time.Duration
+
+	mu             sync.Mutex
+	unhealthyUntil []time.Time
+}
+
+// NewFailoverClient returns a Client that sends requests to primary, with the scheme and host of their URL
+// rewritten, and retries them there as client does. When client runs out of retries for an endpoint, or a
+// request fails without any response, the request is sent to the next of secondaries, with the same per-endpoint
+// retries, and so on. Endpoints that failed are avoided by subsequent requests for cooldown, unless all of them
+// failed, so that requests prefer healthy endpoints while a region is down. Requests with a body are only failed
+// over if the body can be recreated with GetBody, as set by "net/http".NewRequest for in-memory bodies.
+func NewFailoverClient(client *BackoffClient, cooldown time.Duration, primary *url.URL, secondaries ...*url.URL) *FailoverClient {
+	endpoints := append([]*url.URL{primary}, secondaries...)
+	return &FailoverClient{
+		client:         client,
+		endpoints:      endpoints,
+		cooldown:       cooldown,
+		unhealthyUntil: make([]time.Time, len(endpoints)),
+	}
+}
+
+// Do sends req to the healthy endpoints in order, then to the unhealthy ones, until one of them succeeds or
+// fails in a way another endpoint would not fix, such as with a 404 or because the request context is done.
+func (c *FailoverClient) Do(req *http.Request) (*http.Response, error) {
+	var (
+		resp *http.Response
+		err  error
+	)
+	for i, endpoint := range c.order() {
+		endpointReq := req.Clone(req.Context())
+		rewriteToPrimary(endpointReq, c.endpoints[endpoint])
+		if i > 0 {
+			if req.Body != nil && req.Body != http.NoBody {
+				if req.GetBody == nil {
+					break
+				}
+				if endpointReq.Body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+			drainBody(resp)
+		}
+
+		resp, err = c.client.Do(endpointReq)
+		if !endpointFailed(resp, err) || req.Context().Err() != nil {
+			if err == nil {
+				c.markHealthy(endpoint)
+			}
+			return resp, err
+		}
+		c.markUnhealthy(endpoint)
+	}
+
+	return resp, err
+}
+
+// endpointFailed tells whether an endpoint gave up on a request in a way that another endpoint may not.
+func endpointFailed(resp *http.Response, err error) bool {
+	var retryErr *RetryError
+	return err != nil && (resp == nil || errors.As(err, &retryErr))
+}
+
+// order returns the indexes of the endpoints to try, healthy ones first.
+func (c *FailoverClient) order() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.client.now()
+	var healthy, unhealthy []int
+	for i, until := range c.unhealthyUntil {
+		if now.Before(until) {
+			unhealthy = append(unhealthy, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (c *FailoverClient) markUnhealthy(endpoint int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unhealthyUntil[endpoint] = c.client.now().Add(c.cooldown)
+}
+
+func (c *FailoverClient) markHealthy(endpoint int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unhealthyUntil[endpoint] = time.Time{}
+}
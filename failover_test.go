@@ -0,0 +1,96 @@
+package httpeeve
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailoverClient(t *testing.T) {
+	var primaryCount, secondaryCount int64
+	var primaryDown int64 = 1
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&primaryCount, 1)
+		if atomic.LoadInt64(&primaryDown) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer primary.Close()
+	var bodies []string
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&secondaryCount, 1)
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer secondary.Close()
+
+	now := time.Now()
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), retryOn5XX,
+		WithIdempotentOnly(false), WithNow(func() time.Time { return now }))
+	failover := NewFailoverClient(client, time.Minute, mustParseURL(primary.URL), mustParseURL(secondary.URL))
+
+	send := func() *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, primary.URL+"/orders", strings.NewReader("order"))
+		resp, err := failover.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp := send()
+	assert.Equal(t, secondary.URL+"/orders", resp.Request.URL.String())
+	assert.Equal(t, int64(3), atomic.LoadInt64(&primaryCount), "the primary gets all its retries")
+	assert.Equal(t, []string{"order"}, bodies, "the body is sent again")
+
+	atomic.StoreInt64(&primaryDown, 0)
+	send()
+	assert.Equal(t, int64(3), atomic.LoadInt64(&primaryCount), "the primary is avoided while cooling down")
+	assert.Equal(t, int64(2), atomic.LoadInt64(&secondaryCount))
+
+	now = now.Add(time.Minute)
+	resp = send()
+	assert.Equal(t, primary.URL+"/orders", resp.Request.URL.String(), "the primary is preferred again")
+}
+
+func TestFailoverClientKeepsPermanentErrors(t *testing.T) {
+	var secondaryCount int64
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&secondaryCount, 1)
+	}))
+	defer secondary.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+	failover := NewFailoverClient(client, time.Minute, mustParseURL(primary.URL), mustParseURL(secondary.URL))
+
+	req, _ := http.NewRequest(http.MethodGet, primary.URL, nil)
+	resp, err := failover.Do(req)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, int64(0), atomic.LoadInt64(&secondaryCount))
+}
+
+func TestFailoverClientWhenPrimaryIsUnreachable(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	primaryURL := mustParseURL(primary.URL)
+	primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer secondary.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.StopBackOff{}, retryOn5XX)
+	failover := NewFailoverClient(client, time.Minute, primaryURL, mustParseURL(secondary.URL))
+
+	req, _ := http.NewRequest(http.MethodGet, primary.URL, nil)
+	resp, err := failover.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
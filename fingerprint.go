@@ -0,0 +1,57 @@
+package httpeeve
+
+import (
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var errRequestChanged = errors.New("request changed between attempts, refusing to retry")
+
+// WithFingerprintCheck makes the client fingerprint the method, URL, headers and body of a request before
+// its first attempt, and verify that fingerprint before every retry. A request that drifted in between,
+// for instance because something mutated its headers, fails with a permanent error instead of being retried.
+func WithFingerprintCheck() Option {
+	return func(c *BackoffClient) {
+		c.checkFingerprint = true
+	}
+}
+
+func fingerprint(req *http.Request, body io.ReadCloser) ([]byte, error) {
+	hash := sha256.New()
+	io.WriteString(hash, req.Method+"\n"+req.URL.String()+"\n")
+
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range req.Header[key] {
+			io.WriteString(hash, key+": "+value+"\n")
+		}
+	}
+
+	if body != nil {
+		defer body.Close()
+		if _, err := io.Copy(hash, body); err != nil {
+			return nil, err
+		}
+	}
+
+	return hash.Sum(nil), nil
+}
+
+// fingerprinted returns the request of the call as its attempts send it, with the headers renewed by the
+// AuthRefresher if any, to be fingerprinted.
+func (c *call) fingerprinted() *http.Request {
+	if c.authHeader == nil {
+		return c.req
+	}
+	req := c.req.WithContext(c.req.Context())
+	req.Header = c.authHeader
+	return req
+}
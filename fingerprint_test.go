@@ -0,0 +1,53 @@
+package httpeeve
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprintDriftIsPermanent(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewBufferString("payload"))
+	var conditionerCalls int
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, func(resp *http.Response) (bool, error) {
+		conditionerCalls++
+		req.Header.Set("X-Nonce", strconv.Itoa(conditionerCalls)) // a buggy component mutating the request
+		return RetriableError("bad")
+	}, WithFingerprintCheck(), WithIdempotentOnly(false))
+
+	_, err := client.Do(req)
+	assert.Equal(t, errRequestChanged, err)
+	assert.Equal(t, 1, requestCount)
+}
+
+func TestFingerprintAllowsUnchangedRetries(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithFingerprintCheck(), WithIdempotentOnly(false))
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewBufferString("payload"))
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, Attempts(resp))
+}
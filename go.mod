@@ -1,9 +1,10 @@
 module github.com/motain/httpeeve
 
-go 1.12
+go 1.13
 
 require (
 	github.com/cenkalti/backoff v2.2.1+incompatible
 	github.com/pkg/errors v0.8.1
-	github.com/stretchr/testify v1.3.0
+	github.com/stretchr/testify v1.7.0
+	go.uber.org/goleak v1.1.12
 )
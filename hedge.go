@@ -0,0 +1,102 @@
+package httpeeve
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithHedging cuts tail latency of idempotent requests: when an attempt has not returned within delay, up
+// to maxHedges further requests are fired in parallel, each delay apart, and whichever response arrives first
+// is used. The losers are cancelled, and their response bodies drained and closed so no connections leak.
+// A hedged attempt counts as a single attempt.
+func WithHedging(delay time.Duration, maxHedges int) Option {
+	return func(c *BackoffClient) {
+		c.hedgeDelay, c.maxHedges = delay, maxHedges
+	}
+}
+
+// NewHedgedClient returns a client that hedges idempotent requests like WithHedging, and otherwise retries like
+// NewDefaultBackoffClient. Requests with other methods, such as POST, are never hedged.
+func NewHedgedClient(httpClient http.Client, delay time.Duration, maxHedges int, opts ...Option) *BackoffClient {
+	return NewDefaultBackoffClient(httpClient, append([]Option{WithHedging(delay, maxHedges)}, opts...)...)
+}
+
+type hedgeResult struct {
+	resp  *http.Response
+	err   error
+	hedge int
+}
+
+// cancelOnClose releases the context of the winning hedge once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// hedge sends req with httpClient, firing hedges according to the configuration of c. newBody returns a
+// fresh copy of the request body for every hedge.
+func (c *BackoffClient) hedge(httpClient *http.Client, req *http.Request, newBody func() io.ReadCloser) (*http.Response, error) {
+	results := make(chan hedgeResult, c.maxHedges+1)
+	var cancels []context.CancelFunc
+	launch := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		hedgeReq := req.WithContext(ctx)
+		hedgeReq.Body = newBody()
+		hedge := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := httpClient.Do(hedgeReq)
+			results <- hedgeResult{resp: resp, err: err, hedge: hedge}
+		}()
+	}
+
+	launch()
+	launched, pending := 1, 1
+
+	timer := time.NewTimer(c.hedgeDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				for hedge, cancel := range cancels {
+					if hedge != result.hedge {
+						cancel()
+					}
+				}
+				go discardHedges(results, pending)
+				result.resp.Body = &cancelOnClose{ReadCloser: result.resp.Body, cancel: cancels[result.hedge]}
+				return result.resp, nil
+			}
+
+			cancels[result.hedge]()
+			if pending == 0 {
+				return nil, result.err
+			}
+		case <-timer.C:
+			if launched <= c.maxHedges {
+				launch()
+				launched++
+				pending++
+				timer.Reset(c.hedgeDelay)
+			}
+		}
+	}
+}
+
+// discardHedges cleans up after the cancelled hedges that lost the race, closing any response they still got.
+func discardHedges(results <-chan hedgeResult, pending int) {
+	for ; pending > 0; pending-- {
+		drainBody((<-results).resp)
+	}
+}
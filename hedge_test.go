@@ -0,0 +1,98 @@
+package httpeeve
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+)
+
+func TestHedgeLosersAreCancelledWithoutLeaks(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	var requestCount int64
+	loserCancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt64(&requestCount, 1) == 1 {
+			select {
+			case <-req.Context().Done():
+				close(loserCancelled)
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Write([]byte("hedge"))
+	}))
+	defer server.Close()
+
+	transport := &http.Transport{}
+	defer transport.CloseIdleConnections()
+	client := NewBackoffClient(http.Client{Transport: transport}, &backoff.ZeroBackOff{}, retryOn5XX, WithHedging(20*time.Millisecond, 2))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, Attempts(resp))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "hedge", string(body))
+	assert.NoError(t, resp.Body.Close())
+
+	select {
+	case <-loserCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the losing hedge was not cancelled")
+	}
+	assert.Equal(t, int64(2), atomic.LoadInt64(&requestCount), "no further hedges are fired after a winner")
+}
+
+func TestHedgingSkipsNonIdempotentRequests(t *testing.T) {
+	var requestCount int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithHedging(5*time.Millisecond, 2))
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&requestCount))
+}
+
+func TestHedgedClient(t *testing.T) {
+	var requestCount int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt64(&requestCount, 1) == 1 {
+			select {
+			case <-req.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Write([]byte("hedge"))
+	}))
+	defer server.Close()
+
+	client := NewHedgedClient(http.Client{}, 20*time.Millisecond, 1)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	start := time.Now()
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) < time.Second, "the hedge wins")
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "hedge", string(body))
+	resp.Body.Close()
+}
@@ -0,0 +1,72 @@
+package httpeeve
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// Hooks are callbacks the client calls at every step of a request, synchronously and from the goroutine that
+// called Do, see WithHooks. Any of them may be nil. Unlike WithEvents they never drop a step, so they suit
+// logging, metrics and alerting; keep them fast, as the request waits for them.
+//
+// OnAttemptStart and OnAttemptDone get the copy of the request sent by the attempt, so that changes they make to
+// it do not leak into later attempts; OriginalRequest returns the request passed to Do, which the others get.
+type Hooks struct {
+	// OnAttemptStart is called right before an attempt is sent. Attempts are numbered from 1.
+	OnAttemptStart func(req *http.Request, attempt int)
+	// OnAttemptDone is called once an attempt has been judged, with its response, if any, and its error, which
+	// is nil if the response was accepted.
+	OnAttemptDone func(req *http.Request, attempt int, resp *http.Response, err error)
+	// OnRetryScheduled is called when an attempt failed and the client is about to wait delay for the next one.
+	OnRetryScheduled func(req *http.Request, attempt int, delay time.Duration, err error)
+	// OnGiveUp is called when the client returns an error for the request, after attempts attempts.
+	OnGiveUp func(req *http.Request, attempts int, err error)
+}
+
+// WithHooks registers hooks that are called at every step of every request. It can be used more than once, for
+// instance by integrations with metrics or tracing backends; hooks are called in the order they were registered.
+func WithHooks(hooks Hooks) Option {
+	return func(c *BackoffClient) {
+		c.hooks = append(c.hooks, hooks)
+	}
+}
+
+// hookList holds all the Hooks registered on a client.
+type hookList []Hooks
+
+func (l hookList) attemptStart(req *http.Request, attempt int) {
+	for _, h := range l {
+		if h.OnAttemptStart != nil {
+			h.OnAttemptStart(req, attempt)
+		}
+	}
+}
+
+func (l hookList) attemptDone(req *http.Request, attempt int, resp *http.Response, err error) {
+	if permanent, ok := err.(*backoff.PermanentError); ok {
+		err = permanent.Err
+	}
+	for _, h := range l {
+		if h.OnAttemptDone != nil {
+			h.OnAttemptDone(req, attempt, resp, err)
+		}
+	}
+}
+
+func (l hookList) retryScheduled(req *http.Request, attempt int, delay time.Duration, err error) {
+	for _, h := range l {
+		if h.OnRetryScheduled != nil {
+			h.OnRetryScheduled(req, attempt, delay, err)
+		}
+	}
+}
+
+func (l hookList) giveUp(req *http.Request, attempts int, err error) {
+	for _, h := range l {
+		if h.OnGiveUp != nil {
+			h.OnGiveUp(req, attempts, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package httpeeve
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var steps []string
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Millisecond), 1), retryOn5XX, WithHooks(Hooks{
+		OnAttemptStart: func(req *http.Request, attempt int) {
+			steps = append(steps, fmt.Sprintf("start %d", attempt))
+		},
+		OnAttemptDone: func(req *http.Request, attempt int, resp *http.Response, err error) {
+			steps = append(steps, fmt.Sprintf("done %d: %d %v", attempt, resp.StatusCode, err))
+		},
+		OnRetryScheduled: func(req *http.Request, attempt int, delay time.Duration, err error) {
+			steps = append(steps, fmt.Sprintf("retry %d in %s: %v", attempt, delay, err))
+		},
+		OnGiveUp: func(req *http.Request, attempts int, err error) {
+			steps = append(steps, fmt.Sprintf("give up after %d: %v", attempts, err))
+		},
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.Error(t, err)
+	assert.Equal(t, []string{
+		"start 1",
+		"done 1: 503 bad status code 503",
+		"retry 1 in 1ms: bad status code 503",
+		"start 2",
+		"done 2: 503 bad status code 503",
+		"give up after 2: bad status code 503",
+	}, steps)
+}
+
+func TestHooksOnSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	var done []error
+	var gaveUp bool
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithHooks(Hooks{
+		OnAttemptDone: func(req *http.Request, attempt int, resp *http.Response, err error) {
+			done = append(done, err)
+		},
+		OnGiveUp: func(*http.Request, int, error) { gaveUp = true },
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil}, done)
+	assert.False(t, gaveUp)
+}
+
+func TestHooksAreChained(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	var calls []string
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX,
+		WithHooks(Hooks{OnAttemptStart: func(*http.Request, int) { calls = append(calls, "first") }}),
+		WithHooks(Hooks{OnAttemptStart: func(*http.Request, int) { calls = append(calls, "second") }}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestHookChangesDoNotLeakIntoLaterAttempts(t *testing.T) {
+	var nonces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		nonces = append(nonces, req.Header.Get("X-Nonce"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var originals []*http.Request
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1), retryOn5XX, WithHooks(Hooks{
+		OnAttemptStart: func(req *http.Request, attempt int) {
+			if attempt == 1 {
+				req.Header.Set("X-Nonce", "first")
+			}
+			originals = append(originals, OriginalRequest(req))
+		},
+		OnAttemptDone: func(req *http.Request, attempt int, resp *http.Response, err error) {
+			req.Header.Set("X-Nonce", "done")
+		},
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"first", ""}, nonces)
+	assert.Equal(t, []*http.Request{req, req}, originals)
+	assert.Empty(t, req.Header.Get("X-Nonce"))
+}
@@ -0,0 +1,50 @@
+package httpeeve
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+)
+
+// WithHTTP1Fallback is an interop workaround for buggy intermediaries: after an attempt failed with an
+// HTTP/2 protocol error, such as a stream reset or a GOAWAY, the request is retried over HTTP/1.1. This
+// only works if the Transport of the "net/http".Client is an *http.Transport, or nil.
+func WithHTTP1Fallback() Option {
+	return func(c *BackoffClient) {
+		c.http1Fallback = true
+	}
+}
+
+// isHTTP2Error tells whether err was caused by the HTTP/2 protocol. The errors of the HTTP/2 implementation
+// bundled with "net/http" are unexported, so they have to be recognized by their messages.
+func isHTTP2Error(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "http2: ") || strings.Contains(msg, "stream error: ") || strings.Contains(msg, "connection error: ")
+}
+
+// http1Client returns a copy of the "net/http".Client of c that only speaks HTTP/1.1, or false if its
+// Transport cannot be tweaked. The copy is created once, so that its connections are reused.
+func (c *BackoffClient) http1Client() (*http.Client, bool) {
+	c.http1Once.Do(func() {
+		transport, ok := c.httpClient.Transport.(*http.Transport)
+		if c.httpClient.Transport == nil {
+			transport, ok = http.DefaultTransport.(*http.Transport)
+		}
+		if !ok {
+			return
+		}
+
+		transport = transport.Clone()
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{} // a non-nil map disables HTTP/2
+		if transport.TLSClientConfig != nil {
+			transport.TLSClientConfig.NextProtos = nil
+		}
+
+		client := *c.httpClient
+		client.Transport = transport
+		c.http1 = &client
+	})
+
+	return c.http1, c.http1 != nil
+}
@@ -0,0 +1,49 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP1Fallback(t *testing.T) {
+	var protos []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		protos = append(protos, req.Proto)
+		if req.ProtoMajor == 2 {
+			panic(http.ErrAbortHandler) // resets the stream, like a buggy intermediary would
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	client := NewBackoffClient(*server.Client(), &backoff.ZeroBackOff{}, retryOn5XX, WithHTTP1Fallback())
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1", resp.Proto)
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Equal(t, []string{"HTTP/2.0", "HTTP/1.1"}, protos)
+}
+
+func TestHTTP2ErrorsArePermanentWithoutFallback(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	client := NewBackoffClient(*server.Client(), &backoff.ZeroBackOff{}, retryOn5XX)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.Error(t, err)
+	assert.True(t, isHTTP2Error(err), err.Error())
+}
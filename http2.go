@@ -0,0 +1,54 @@
+package httpeeve
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// http2ErrorCodePattern matches the error code in the messages of stream errors, "stream error: stream ID 3;
+// REFUSED_STREAM", and of GOAWAY frames, "... LastStreamID=5, ErrCode=ENHANCE_YOUR_CALM, ...".
+var http2ErrorCodePattern = regexp.MustCompile(`(?:stream error: stream ID \d+; |ErrCode=)([A-Z_]+)`)
+
+// HTTP2ErrorCode returns the error code of the HTTP/2 stream reset or GOAWAY that caused err, such as
+// "REFUSED_STREAM" or "ENHANCE_YOUR_CALM", or false if err was not caused by one. The errors of the HTTP/2
+// implementation bundled with "net/http" are unexported, so, like those of golang.org/x/net/http2, they are
+// recognized by their messages.
+func HTTP2ErrorCode(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	match := http2ErrorCodePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// WithRetryRefusedStreams retries requests whose HTTP/2 stream the server refused with REFUSED_STREAM whatever
+// their method, even when WithIdempotentOnly or NonRetriableMethods would not retry them: RFC 7540 guarantees
+// that such a request was not processed. They are retried with the backoff, for idempotent methods by default.
+func WithRetryRefusedStreams() Option {
+	return func(c *BackoffClient) {
+		c.retryRefusedStreams = true
+	}
+}
+
+// isRetriableHTTP2Error tells whether err is an HTTP/2 stream reset or GOAWAY that is worth retrying: the
+// server refused the stream, asked to calm down or shut the connection down gracefully.
+func isRetriableHTTP2Error(err error) bool {
+	if isGoAway(err) {
+		return true
+	}
+	code, _ := HTTP2ErrorCode(err)
+	return code == "REFUSED_STREAM" || code == "ENHANCE_YOUR_CALM"
+}
+
+// retriesUnprocessed tells whether the attempt that got resp and err is retried whatever its method, because
+// it is known not to have been processed.
+func (c *BackoffClient) retriesUnprocessed(resp *http.Response, err error) bool {
+	if !c.retryRefusedStreams || resp != nil {
+		return false
+	}
+	code, ok := HTTP2ErrorCode(err)
+	return ok && code == "REFUSED_STREAM"
+}
@@ -0,0 +1,60 @@
+package httpeeve
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP2ErrorCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code string
+		ok   bool
+	}{
+		{errors.New("stream error: stream ID 3; REFUSED_STREAM"), "REFUSED_STREAM", true},
+		{fmt.Errorf("Post: %w", errors.New("stream error: stream ID 1; INTERNAL_ERROR; received from peer")), "INTERNAL_ERROR", true},
+		{errors.New(`http2: server sent GOAWAY and closed the connection; LastStreamID=7, ErrCode=ENHANCE_YOUR_CALM, debug="slow down"`), "ENHANCE_YOUR_CALM", true},
+		{errors.New("connection refused"), "", false},
+		{nil, "", false},
+	} {
+		code, ok := HTTP2ErrorCode(tc.err)
+		assert.Equal(t, tc.ok, ok, "%v", tc.err)
+		assert.Equal(t, tc.code, code, "%v", tc.err)
+	}
+}
+
+func TestWithRetryRefusedStreams(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		failure  string
+		opts     []Option
+		attempts int
+	}{
+		{"refused POST", "stream error: stream ID 1; REFUSED_STREAM", []Option{WithRetryRefusedStreams()}, 2},
+		{"refused POST by default", "stream error: stream ID 1; REFUSED_STREAM", nil, 1},
+		{"refused non-retriable method", "stream error: stream ID 1; REFUSED_STREAM", []Option{WithRetryRefusedStreams(), NonRetriableMethods(http.MethodPost)}, 2},
+		{"reset POST", "stream error: stream ID 1; ENHANCE_YOUR_CALM", []Option{WithRetryRefusedStreams()}, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int
+			transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				attempts++
+				if attempts == 1 {
+					return nil, errors.New(tc.failure)
+				}
+				return &http.Response{StatusCode: http.StatusCreated, Body: http.NoBody, Request: req}, nil
+			})
+			client := NewBackoffClient(http.Client{Transport: transport}, &backoff.ZeroBackOff{}, retryOn5XX, tc.opts...)
+
+			req, _ := http.NewRequest(http.MethodPost, "http://localhost/items", strings.NewReader("item"))
+			_, _ = client.Do(req)
+			assert.Equal(t, tc.attempts, attempts)
+		})
+	}
+}
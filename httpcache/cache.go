@@ -0,0 +1,321 @@
+// Package httpcache caches the responses of an httpeeve client, honoring Cache-Control, ETag and Last-Modified,
+// and serves stale responses when the retries for a fresh one ran out, as stale-if-error of RFC 5861 describes.
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/motain/httpeeve"
+)
+
+// Entry is a response stored in a Storage.
+type Entry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Stored is when the response was received.
+	Stored time.Time
+	// Vary holds the values of the request headers named by the Vary header of the response.
+	Vary map[string]string
+}
+
+// Storage stores the entries of a Client by key. Implementations must be safe for concurrent use.
+type Storage interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry)
+	Delete(key string)
+}
+
+// CacheStatus tells how a response of a Client came about, see Status.
+type CacheStatus int
+
+const (
+	// Miss is a response that was not cached.
+	Miss CacheStatus = iota
+	// Hit is a fresh cached response, served without sending the request.
+	Hit
+	// Revalidated is a cached response that the server confirmed to be unchanged with a 304 Not Modified.
+	Revalidated
+	// Stale is a cached response served because the request failed.
+	Stale
+)
+
+type contextKeyStatus struct{}
+
+// Status returns how resp came about. Responses that did not come from a Client are a Miss.
+func Status(resp *http.Response) CacheStatus {
+	if resp == nil || resp.Request == nil {
+		return Miss
+	}
+	status, _ := resp.Request.Context().Value(contextKeyStatus{}).(CacheStatus)
+	return status
+}
+
+// DefaultMaxEntrySize is the size limit of the bodies a Client stores, unless WithMaxEntrySize sets another one.
+const DefaultMaxEntrySize = 1 << 20
+
+// Client is an httpeeve.Client that caches the responses of another one to GET requests.
+type Client struct {
+	client       httpeeve.Client
+	storage      Storage
+	staleIfError time.Duration
+	maxEntrySize int64
+	now          func() time.Time
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithStaleIfError serves cached responses that are stale by up to maxStale when a request fails, be it
+// without a response or with a 5XX one, after the retries of the wrapped client ran out. A stale-if-error
+// directive of the cached response takes precedence, a must-revalidate directive forbids it. Without this
+// option, only responses with a stale-if-error directive are served stale.
+func WithStaleIfError(maxStale time.Duration) Option {
+	return func(c *Client) {
+		c.staleIfError = maxStale
+	}
+}
+
+// WithMaxEntrySize limits the size of the bodies the Client stores to maxBytes, instead of DefaultMaxEntrySize.
+func WithMaxEntrySize(maxBytes int64) Option {
+	return func(c *Client) {
+		c.maxEntrySize = maxBytes
+	}
+}
+
+// WithNow replaces how the Client tells the time, which defaults to time.Now.
+func WithNow(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+// New creates a Client caching the responses of client in storage, for instance an LRU.
+func New(client httpeeve.Client, storage Storage, opts ...Option) *Client {
+	c := &Client{client: client, storage: storage, maxEntrySize: DefaultMaxEntrySize, now: time.Now}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Do serves req from the cache if it holds a fresh response, and otherwise sends it with the wrapped client,
+// revalidating the cached response if it has an ETag or a Last-Modified header. Requests with other methods
+// pass through; those that may change the resource, such as POST, invalidate its cached response if they
+// succeed.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+	switch req.Method {
+	case "", http.MethodGet:
+	case http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return c.client.Do(req)
+	default:
+		resp, err := c.client.Do(req)
+		if err == nil && resp.StatusCode < 400 {
+			c.storage.Delete(key)
+		}
+		return resp, err
+	}
+
+	requestCC := parseCacheControl(req.Header)
+	if requestCC.has("no-store") {
+		return c.client.Do(req)
+	}
+
+	entry, ok := c.storage.Get(key)
+	if ok && !entry.matches(req) {
+		entry, ok = nil, false
+	}
+	if ok && !requestCC.has("no-cache") && c.age(entry) < entry.freshnessLifetime() {
+		return c.respond(req, entry, Hit), nil
+	}
+
+	sent := req
+	if ok {
+		sent = revalidating(req, entry)
+	}
+	resp, err := c.client.Do(sent)
+
+	if ok && resp != nil && resp.StatusCode == http.StatusNotModified {
+		drain(resp)
+		entry = entry.refreshed(resp.Header, c.now())
+		c.storage.Set(key, entry)
+		return c.respond(req, entry, Revalidated), nil
+	}
+
+	if failed(resp, err) {
+		if ok && c.age(entry) <= entry.freshnessLifetime()+c.staleIfErrorOf(entry) {
+			drain(resp)
+			return c.respond(req, entry, Stale), nil
+		}
+		return resp, err
+	}
+
+	if err == nil {
+		return c.store(key, req, resp)
+	}
+	return resp, err
+}
+
+// failed tells whether a request failed in a way that allows serving a stale response.
+func failed(resp *http.Response, err error) bool {
+	if resp == nil {
+		return err != nil
+	}
+	return resp.StatusCode >= 500
+}
+
+// store stores resp if it may be cached, and returns it with a body that can still be read.
+func (c *Client) store(key string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	cc := parseCacheControl(resp.Header)
+	if !cacheableStatuses[resp.StatusCode] || cc.has("no-store") || resp.Header.Get("Vary") == "*" {
+		return resp, nil
+	}
+	if resp.ContentLength > c.maxEntrySize {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.maxEntrySize+1))
+	if err != nil {
+		resp.Body.Close()
+		return resp, fmt.Errorf("reading response body: %w", err)
+	}
+	if int64(len(body)) > c.maxEntrySize {
+		// too large, hand back the full body
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	c.storage.Set(key, &Entry{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		Stored:     c.now(),
+		Vary:       varyValues(req, resp.Header),
+	})
+	return resp, nil
+}
+
+// respond creates a response to req from entry.
+func (c *Client) respond(req *http.Request, entry *Entry, status CacheStatus) *http.Response {
+	header := entry.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(c.age(entry)/time.Second), 10))
+	if status == Stale {
+		header.Add("Warning", `110 - "Response is Stale"`)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.StatusCode, http.StatusText(entry.StatusCode)),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req.WithContext(context.WithValue(req.Context(), contextKeyStatus{}, status)),
+	}
+}
+
+// age returns how old the response of entry is, including the time it spent in other caches.
+func (c *Client) age(entry *Entry) time.Duration {
+	age := c.now().Sub(entry.Stored)
+	if seconds, err := strconv.ParseInt(entry.Header.Get("Age"), 10, 64); err == nil && seconds > 0 {
+		age += time.Duration(seconds) * time.Second
+	}
+	return age
+}
+
+// staleIfErrorOf returns how long after it went stale entry may be served when a request fails.
+func (c *Client) staleIfErrorOf(entry *Entry) time.Duration {
+	cc := parseCacheControl(entry.Header)
+	if cc.has("must-revalidate") || cc.has("proxy-revalidate") {
+		return -1
+	}
+	if staleIfError, ok := cc.seconds("stale-if-error"); ok {
+		return staleIfError
+	}
+	return c.staleIfError
+}
+
+func (e *Entry) freshnessLifetime() time.Duration {
+	cc := parseCacheControl(e.Header)
+	if cc.has("no-cache") {
+		return 0
+	}
+	return freshnessLifetime(e.Header, cc)
+}
+
+// matches tells whether the request headers named by the Vary header of the response of e are the same in req.
+func (e *Entry) matches(req *http.Request) bool {
+	for name, value := range e.Vary {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// refreshed returns a copy of e updated with the headers of a 304 Not Modified response received at now.
+func (e *Entry) refreshed(header http.Header, now time.Time) *Entry {
+	refreshed := *e
+	refreshed.Header = e.Header.Clone()
+	for name, values := range header {
+		if name == "Content-Length" {
+			continue
+		}
+		refreshed.Header[name] = values
+	}
+	refreshed.Header.Del("Age")
+	refreshed.Stored = now
+	return &refreshed
+}
+
+// revalidating returns a copy of req asking the server whether the response of entry is still valid.
+func revalidating(req *http.Request, entry *Entry) *http.Request {
+	etag, lastModified := entry.Header.Get("ETag"), entry.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return req
+	}
+
+	revalidating := req.Clone(req.Context())
+	if etag != "" && revalidating.Header.Get("If-None-Match") == "" {
+		revalidating.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" && revalidating.Header.Get("If-Modified-Since") == "" {
+		revalidating.Header.Set("If-Modified-Since", lastModified)
+	}
+	return revalidating
+}
+
+func varyValues(req *http.Request, header http.Header) map[string]string {
+	var values map[string]string
+	for _, vary := range header["Vary"] {
+		for _, name := range splitList(vary) {
+			if values == nil {
+				values = map[string]string{}
+			}
+			values[http.CanonicalHeaderKey(name)] = req.Header.Get(name)
+		}
+	}
+	return values
+}
+
+func drain(resp *http.Response) {
+	if resp != nil && resp.Body != nil {
+		_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4<<10))
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,218 @@
+package httpcache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/motain/httpeeve"
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Date(2019, time.May, 2, 10, 0, 0, 0, time.UTC)
+
+// clock is a time that tests move forward by hand.
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func retrying() *httpeeve.BackoffClient {
+	return httpeeve.NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), httpeeve.RetryOnStatusRange(500, 599))
+}
+
+func get(t *testing.T, client httpeeve.Client, url string) (*http.Response, string) {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, err := client.Do(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestFreshResponsesAreServedFromTheCache(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	now := &clock{epoch}
+	client := New(retrying(), NewLRU(10), WithNow(now.Now))
+
+	resp, body := get(t, client, server.URL)
+	assert.Equal(t, Miss, Status(resp))
+	assert.Equal(t, "hello", body)
+
+	now.now = now.now.Add(30 * time.Second)
+	resp, body = get(t, client, server.URL)
+	assert.Equal(t, Hit, Status(resp))
+	assert.Equal(t, "hello", body)
+	assert.Equal(t, "30", resp.Header.Get("Age"))
+	assert.Equal(t, 1, requestCount)
+
+	now.now = now.now.Add(time.Minute)
+	resp, _ = get(t, client, server.URL)
+	assert.Equal(t, Miss, Status(resp))
+	assert.Equal(t, 2, requestCount)
+}
+
+func TestStaleResponsesAreRevalidated(t *testing.T) {
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conditional = append(conditional, req.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=10")
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	now := &clock{epoch}
+	client := New(retrying(), NewLRU(10), WithNow(now.Now))
+
+	get(t, client, server.URL)
+	now.now = now.now.Add(time.Minute)
+	resp, body := get(t, client, server.URL)
+	assert.Equal(t, Revalidated, Status(resp))
+	assert.Equal(t, "hello", body)
+	assert.Equal(t, []string{"", `"v1"`}, conditional)
+
+	resp, _ = get(t, client, server.URL)
+	assert.Equal(t, Hit, Status(resp), "revalidation makes the response fresh again")
+}
+
+func TestStaleIfError(t *testing.T) {
+	var requestCount int
+	down := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=10")
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	now := &clock{epoch}
+	client := New(retrying(), NewLRU(10), WithNow(now.Now), WithStaleIfError(time.Hour))
+
+	get(t, client, server.URL)
+	down, requestCount = true, 0
+	now.now = now.now.Add(time.Minute)
+
+	resp, body := get(t, client, server.URL)
+	assert.Equal(t, Stale, Status(resp))
+	assert.Equal(t, "hello", body)
+	assert.Equal(t, `110 - "Response is Stale"`, resp.Header.Get("Warning"))
+	assert.Equal(t, 3, requestCount, "stale responses are served once the retries ran out")
+
+	now.now = now.now.Add(2 * time.Hour)
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.EqualError(t, err, "bad status code 503", "too stale")
+}
+
+func TestStaleIfErrorDirectives(t *testing.T) {
+	cacheControl := "max-age=10, stale-if-error=120"
+	down := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if down {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Cache-Control", cacheControl)
+	}))
+	defer server.Close()
+
+	now := &clock{epoch}
+	client := New(retrying(), NewLRU(10), WithNow(now.Now))
+	get(t, client, server.URL)
+	down = true
+	now.now = now.now.Add(time.Minute)
+
+	resp, _ := get(t, client, server.URL)
+	assert.Equal(t, Stale, Status(resp), "the directive allows it without WithStaleIfError")
+
+	down, cacheControl = false, "max-age=10, must-revalidate"
+	client = New(retrying(), NewLRU(10), WithNow(now.Now), WithStaleIfError(time.Hour))
+	get(t, client, server.URL)
+	down = true
+	now.now = now.now.Add(time.Minute)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.Error(t, err, "must-revalidate forbids it")
+}
+
+func TestUncacheableResponses(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		switch req.URL.Path {
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+		case "/large":
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+		}
+	}))
+	defer server.Close()
+
+	client := New(retrying(), NewLRU(10), WithMaxEntrySize(10))
+
+	for _, path := range []string{"/no-store", "/large"} {
+		requestCount = 0
+		get(t, client, server.URL+path)
+		resp, body := get(t, client, server.URL+path)
+		assert.Equal(t, Miss, Status(resp), path)
+		assert.Equal(t, 2, requestCount, path)
+		if path == "/large" {
+			assert.Len(t, body, 100)
+		}
+	}
+
+	requestCount = 0
+	for _, language := range []string{"en", "de", "de"} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/vary", nil)
+		req.Header.Set("Accept-Language", language)
+		_, err := client.Do(req)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, requestCount, "responses vary by language")
+}
+
+func TestUnsafeRequestsInvalidate(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		w.Header().Set("Cache-Control", "max-age=60")
+	}))
+	defer server.Close()
+
+	client := New(retrying(), NewLRU(10))
+	get(t, client, server.URL)
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL, nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+
+	resp, _ := get(t, client, server.URL)
+	assert.Equal(t, Miss, Status(resp))
+	assert.Equal(t, 3, requestCount)
+}
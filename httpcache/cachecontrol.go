@@ -0,0 +1,92 @@
+package httpcache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheControl holds the directives of a Cache-Control header, with the values of those that have one.
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	directives := cacheControl{}
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range splitList(value) {
+			name, arg := directive, ""
+			if i := strings.IndexByte(directive, '='); i >= 0 {
+				name, arg = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+			}
+			directives[strings.ToLower(strings.TrimSpace(name))] = arg
+		}
+	}
+	return directives
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// seconds returns the duration of a directive such as max-age, or false if it is missing or invalid.
+func (cc cacheControl) seconds(directive string) (time.Duration, bool) {
+	arg, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	if seconds > int64(1<<63-1)/int64(time.Second) {
+		seconds = int64(1<<63-1) / int64(time.Second)
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// freshnessLifetime returns how long a response with header stays fresh after it was generated, according to
+// its max-age directive or, failing that, its Expires and Date headers.
+func freshnessLifetime(header http.Header, cc cacheControl) time.Duration {
+	if maxAge, ok := cc.seconds("max-age"); ok {
+		return maxAge
+	}
+
+	expires, err := http.ParseTime(header.Get("Expires"))
+	if err != nil {
+		return 0
+	}
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return 0
+	}
+	if lifetime := expires.Sub(date); lifetime > 0 {
+		return lifetime
+	}
+	return 0
+}
+
+// cacheableStatuses are the status codes of responses that may be stored.
+var cacheableStatuses = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// splitList splits a comma-separated header value into its trimmed, non-empty elements.
+func splitList(value string) []string {
+	var elements []string
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
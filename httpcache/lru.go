@@ -0,0 +1,79 @@
+package httpcache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// LRU is an in-memory Storage holding up to a fixed number of entries, evicting the least recently used one to
+// make room for another. It is safe for concurrent use.
+type LRU struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   list.List
+	entries map[string]*list.Element
+}
+
+type lruItem struct {
+	key   string
+	entry *Entry
+}
+
+// NewLRU creates an LRU holding up to capacity entries, at least one.
+func NewLRU(capacity int) *LRU {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LRU{capacity: capacity, entries: map[string]*list.Element{}}
+}
+
+// Get returns the entry stored for key, if any, and marks it as recently used.
+func (l *LRU) Get(key string) (*Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return element.Value.(*lruItem).entry, true
+}
+
+// Set stores entry for key, evicting the least recently used entry if the LRU is full.
+func (l *LRU) Set(key string, entry *Entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		element.Value.(*lruItem).entry = entry
+		l.order.MoveToFront(element)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&lruItem{key: key, entry: entry})
+	if l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruItem).key)
+	}
+}
+
+// Delete removes the entry stored for key, if any.
+func (l *LRU) Delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		l.order.Remove(element)
+		delete(l.entries, key)
+	}
+}
+
+// Len returns the number of entries stored.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
@@ -0,0 +1,31 @@
+package httpcache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	lru := NewLRU(2)
+	lru.Set("a", &Entry{StatusCode: 1})
+	lru.Set("b", &Entry{StatusCode: 2})
+	_, _ = lru.Get("a")
+	lru.Set("c", &Entry{StatusCode: 3})
+
+	_, ok := lru.Get("b")
+	assert.False(t, ok)
+	a, ok := lru.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, a.StatusCode)
+	assert.Equal(t, 2, lru.Len())
+
+	lru.Set("a", &Entry{StatusCode: 4})
+	a, _ = lru.Get("a")
+	assert.Equal(t, 4, a.StatusCode)
+
+	lru.Delete("a")
+	_, ok = lru.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, lru.Len())
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net"
 	"net/http"
-	"strings"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/cenkalti/backoff"
 	"github.com/pkg/errors"
@@ -20,7 +23,75 @@ type (
 		Do(*http.Request) (*http.Response, error)
 	}
 
-	clientFunc func(*http.Request) (*http.Response, error)
+	// BackoffClient is the Client implementation returned by NewBackoffClient.
+	BackoffClient struct {
+		httpClient     *http.Client
+		backoffer      backoff.BackOff
+		backoffFactory func() backoff.BackOff
+		conditioner    Conditioner
+		events         chan RetryEvent
+
+		checkFingerprint bool
+		retriableErrnos  []syscall.Errno
+
+		retryOnAttemptTimeout bool
+		divergenceHook        DivergenceHook
+		sheddingThreshold     int
+		learnDelays           bool
+		namedPolicies         map[string]Policy
+		deadlineFraction      float64
+		sourceRetries         map[string]int
+		http1Fallback         bool
+		collectorBudget       int64
+		hedgeDelay            time.Duration
+		maxHedges             int
+		metrics               MetricsSink
+		bodyTransform         BodyTransform
+		retryOnBodyReadError  bool
+		tracer                *chromeTracer
+		nonRetriableMethods   map[string]bool
+		deadlineHeader        string
+		deadlineFormat        DeadlineFormat
+		maxRetries            int
+		maxElapsed            time.Duration
+		onRetry               func(err error, attempt int, next time.Duration)
+		idempotentOnly        bool
+		attemptTimeout        time.Duration
+		maxBufferedBody       int64
+		honorRetryAfter       bool
+		maxRetryAfter         time.Duration
+		hooks                 hookList
+		breaker               *circuitBreaker
+		budget                *retryBudget
+		concurrency           *concurrencyLimiter
+		maxJSONResponse       int64
+		redirects             map[int]bool
+		cookiesAcrossAttempts bool
+		authRefresher         AuthRefresher
+		overallTimeout        time.Duration
+		recorder              Recorder
+		retryRefusedStreams   bool
+		classifiers           []ErrorClassifier
+		maxBufferedResponse   int64
+		maxDrain              int64
+		policyRoutes          []policyRoute
+		idempotencyKeyHeader  string
+		endpointSelector      EndpointSelector
+		throttle              *adaptiveThrottle
+
+		now   func() time.Time
+		sleep func(time.Duration)
+
+		inFlight  int64
+		http1Once sync.Once
+		http1     *http.Client
+		learned   *learnedDelays
+
+		endpointTransports sync.Map
+	}
+
+	// Option configures optional behaviour of a BackoffClient.
+	Option func(*BackoffClient)
 
 	// Conditioner determines whether a response is erroneous and whether to retry it.
 	Conditioner func(resp *http.Response) (shouldRetry bool, err error)
@@ -28,78 +99,441 @@ type (
 	contextKeyAttempts struct{}
 )
 
-func (c clientFunc) Do(req *http.Request) (*http.Response, error) {
-	return c(req)
+// NewClient returns a Client implementation. It takes a Conditioner which determines when to stop or continue
+// retrying. By default requests are retried with an exponential backoff, for as long as it allows; this and
+// further behaviour can be configured with options. Requests are sent with a copy of httpClient, unless
+// WithHTTPClient passes the one to use.
+func NewClient(httpClient http.Client, conditioner Conditioner, opts ...Option) *BackoffClient {
+	c := &BackoffClient{
+		httpClient:  &httpClient,
+		backoffer:   backoff.NewExponentialBackOff(),
+		conditioner: conditioner,
+
+		retryOnAttemptTimeout: true,
+		idempotentOnly:        true,
+		maxRetries:            -1,
+		learned:               newLearnedDelays(),
+		now:                   time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 // NewBackoffClient returns a Client implementation. It takes an implementation of backoff.Backoff,
 // which determines the rate and limits of retrying. It takes a Conditioner which determines when to
-// stop or continue retrying.
-func NewBackoffClient(httpClient http.Client, backoffer backoff.BackOff, conditioner Conditioner) Client {
-	return clientFunc(func(req *http.Request) (*http.Response, error) {
-		var resp *http.Response
-		var attempts int
-
-		getBody := func() io.ReadCloser { return nil }
-		if req.Body != nil {
-			bodyBytes, err := readBody(req.Body)
-			if err != nil {
-				return nil, err
-			}
-			getBody = func() io.ReadCloser {
-				return ioutil.NopCloser(bytes.NewReader(bodyBytes))
-			}
-		}
+// stop or continue retrying. Further behaviour can be configured with options.
+//
+// A BackoffClient is safe for concurrent use as long as its backoff is: every request works on a copy of
+// backoffer, except for backoffs that cannot be copied, which are best created per request with
+// WithBackoffFactory instead.
+func NewBackoffClient(httpClient http.Client, backoffer backoff.BackOff, conditioner Conditioner, opts ...Option) *BackoffClient {
+	return NewClient(httpClient, conditioner, append([]Option{WithBackoff(backoffer)}, opts...)...)
+}
 
-		err := backoff.Retry(func() error {
-			attempts++
+// Do sends the request, retrying it for as long as the Conditioner and the backoff allow.
+func (c *BackoffClient) Do(req *http.Request) (resp *http.Response, err error) {
+	defer recoverDo(&resp, &err)
 
-			var reqErr error
-			req.Body = getBody() // so we can re-read the request body over again
+	if c.overallTimeout <= 0 {
+		return c.do(req)
+	}
 
-			resp, reqErr = httpClient.Do(req)
-			if reqErr != nil {
-				return categorizeRequestError(reqErr)
-			}
+	ctx, cancel := context.WithTimeout(req.Context(), c.overallTimeout)
+	resp, err = c.do(req.WithContext(ctx))
+	if resp == nil || resp.Body == nil {
+		cancel()
+	} else {
+		// the body is still to be read within the overall timeout
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	}
+	return resp, err
+}
 
-			var shouldRetry bool
-			shouldRetry, reqErr = conditioner(resp)
-			if reqErr == nil {
-				return nil
-			}
+func (c *BackoffClient) do(req *http.Request) (resp *http.Response, err error) {
+	if c.concurrency != nil {
+		release, err := c.concurrency.acquire(req)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
 
-			if shouldRetry {
-				return reqErr
-			}
+	atomic.AddInt64(&c.inFlight, 1)
+	defer atomic.AddInt64(&c.inFlight, -1)
+	start := time.Now()
 
-			return backoff.Permanent(reqErr)
-		}, backoffer)
+	policy := c.policyFor(req)
+	call := &call{
+		client:      c,
+		req:         req,
+		conditioner: policy.Conditioner,
+		getBody:     func() io.ReadCloser { return nil },
+		divergence:  divergenceObserver{hook: c.divergenceHook},
+		backoffer:   &suggestingBackOff{BackOff: policy.BackOff, now: c.now},
+	}
+	defer func() { recordResult(req, call.attempts, start) }()
+	defer call.recoverPanic(&resp, &err)
+	if len(c.redirects) > 0 {
+		call.conditioner = c.redirectConditioner(call.conditioner)
+	}
+	call.jar = c.newCookieJar()
+	if c.honorRetryAfter {
+		call.conditioner = HonorRetryAfterUpTo(c.maxRetryAfter, call.conditioner)
+	}
+	if c.collectorBudget > 0 {
+		call.collector = &responseCollector{budget: c.collectorBudget}
+	}
+	if c.learnDelays {
+		call.backoffer.learned, call.backoffer.host = c.learned, req.URL.Host
+	}
 
-		addAttemptsToRequest(resp, attempts)
-		return resp, err
+	release, err := call.prepareBody()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := call.takeFingerprint(); err != nil {
+		return nil, err
+	}
+	if err := call.takeIdempotencyKey(); err != nil {
+		return nil, err
+	}
+
+	var schedule backoff.BackOff = call.backoffer
+	if c.deadlineFraction > 0 {
+		schedule = &deadlineClampBackOff{BackOff: schedule, ctx: req.Context(), fraction: c.deadlineFraction, now: c.now}
+	}
+	maxRetries := c.maxRetries
+	if policy.MaxAttempts > 0 {
+		maxRetries = policy.MaxAttempts - 1
+	}
+	limit := &limitBackOff{BackOff: schedule, maxRetries: maxRetries, maxElapsed: c.maxElapsed, now: c.now}
+	deadlineStop := &deadlineStopBackOff{BackOff: limit, ctx: req.Context(), now: c.now}
+	budgetStop := &budgetBackOff{BackOff: deadlineStop, budget: c.budget}
+
+	// stop retrying as soon as the caller walked away
+	err = c.retryNotify(call.attempt, backoff.WithContext(budgetStop, req.Context()), func(err error, next time.Duration) {
+		call.wait = next
+		c.incRetry(req, call.resp)
+		if c.onRetry != nil {
+			c.onRetry(err, call.attempts, next)
+		}
+		c.hooks.retryScheduled(req, call.attempts, next, err)
+		c.publish(RetryEvent{Type: Retrying, Request: req, Attempt: call.attempts, Delay: next, Err: err})
 	})
+	if budgetStop.exhausted {
+		err = budgetExhausted(err)
+	} else if err != nil && (!call.permanent || call.panic != nil) {
+		err = call.retryError(limit.exceeded)
+	}
+	err = withContextError(req, call.attempts, err, deadlineStop.stopped, c.now)
+	if c.metrics != nil {
+		c.metrics.ObserveAttempts(call.attempts)
+	}
+	if c.tracer != nil {
+		c.tracer.trace(req, call.log)
+	}
+	if c.recorder != nil {
+		c.recorder.Record(call.recording(err))
+	}
+
+	if err != nil {
+		c.hooks.giveUp(req, call.attempts, err)
+		c.publish(RetryEvent{Type: Exhausted, Request: req, Attempt: call.attempts, Err: err})
+	} else {
+		c.publish(RetryEvent{Type: Succeeded, Request: req, Attempt: call.attempts})
+	}
+
+	addAttemptsToRequest(call.resp, call.attempts)
+	addDrainedBytesToRequest(call.resp, call.drained)
+	addCollectedToRequest(call.resp, call.collector)
+	addAttemptLogToRequest(call.resp, call.log)
+	return call.resp, err
 }
 
-func readBody(body io.ReadCloser) ([]byte, error) {
-	return ioutil.ReadAll(body)
+// call holds the state of a single request sent through a BackoffClient, across all of its attempts.
+type call struct {
+	client      *BackoffClient
+	req         *http.Request
+	resp        *http.Response
+	conditioner Conditioner
+	// attemptReq is the copy of req sent by the latest attempt.
+	attemptReq *http.Request
+
+	attempts     int
+	sent         bool
+	latency      time.Duration
+	drained      int64
+	body         []byte
+	getBody      func() io.ReadCloser
+	unreplayable bool
+
+	fingerprint     []byte
+	idempotencyKey  string
+	divergence      divergenceObserver
+	sourceFailures  map[string]int
+	forceHTTP1      bool
+	jar             http.CookieJar
+	authRefreshed   bool
+	authHeader      http.Header
+	primary         *url.URL // where a failover hint sent the request, see RetryOnFailoverHint
+	endpoint        Endpoint
+	failedEndpoints []Endpoint
+	collector       *responseCollector
+
+	errors    []error
+	permanent bool
+	panic     *PanicError
+
+	log       []AttemptLogEntry
+	trigger   string
+	wait      time.Duration
+	backoffer *suggestingBackOff
 }
 
-func categorizeRequestError(reqErr error) error {
-	if strings.Contains(reqErr.Error(), "EOF") {
-		return reqErr
+func (c *call) attempt() (err error) {
+	c.sent = false
+	logged := len(c.log)
+	defer func() {
+		if value := recover(); value != nil {
+			err = c.panicked(value, logged)
+		}
+	}()
+
+	err = c.throttledTry()
+	if len(c.log) > logged {
+		c.recordOutcome(err)
+	}
+	if c.sent {
+		c.client.hooks.attemptDone(c.attemptReq, c.attempts, c.resp, err)
+		if c.shouldRefreshAuth(err) {
+			return c.refreshAuthAndRetry(err)
+		}
+	}
+	if err == nil {
+		if c.client.budget != nil && c.attempts == 1 {
+			c.client.budget.deposit()
+		}
+		return nil
+	}
+
+	c.trigger = retryTrigger(c.resp, err)
+
+	permanent, isPermanent := err.(*backoff.PermanentError)
+	if isPermanent {
+		c.errors = append(c.errors, permanent.Err)
+	} else {
+		c.errors = append(c.errors, err)
+	}
+	c.permanent = isPermanent
+
+	if !isPermanent && (c.client.shouldShedLoad() || !c.client.retriesMethod(c.req.Method) && !c.client.retriesUnprocessed(c.resp, err)) {
+		c.permanent = true
+		return backoff.Permanent(err)
+	}
+
+	return err
+}
+
+// guardedTry makes an attempt if the circuit breaker of the host allows it, and tells the breaker how it went.
+func (c *call) guardedTry() error {
+	breaker := c.client.breaker
+	if breaker == nil {
+		return c.try()
+	}
+
+	host := c.host()
+	if err := breaker.allow(host); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	err := c.try()
+	_, isPermanent := err.(*backoff.PermanentError)
+	switch {
+	case !c.sent:
+		breaker.record(host, attemptNotSent)
+	case err != nil && (!isPermanent || c.resp == nil && c.req.Context().Err() == nil):
+		// permanent errors without a response, such as a host that does not exist, tell the host is down as well,
+		// unless the caller walked away
+		breaker.record(host, attemptFailed)
+	default:
+		breaker.record(host, attemptSucceeded)
+	}
+	return err
+}
+
+func (c *call) try() error {
+	if c.unreplayable && c.attempts > 0 {
+		return backoff.Permanent(errBodyNotReplayable)
+	}
+
+	c.attempts++
+
+	if c.attempts == 1 {
+		c.trigger = "initial"
+	}
+	c.log = append(c.log, AttemptLogEntry{Attempt: c.attempts, Trigger: c.trigger, Wait: c.wait})
+
+	if c.client.checkFingerprint && c.attempts > 1 {
+		actualFingerprint, err := fingerprint(c.fingerprinted(), c.getBody())
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		if !bytes.Equal(actualFingerprint, c.fingerprint) {
+			return backoff.Permanent(errRequestChanged)
+		}
+	}
+
+	// the previous response is about to be replaced
+	if c.collector != nil {
+		c.drained += c.collector.collect(c.attempts-1, c.resp, c.client.maxDrain)
+	} else {
+		c.drained += drainBodyUpTo(c.resp, c.client.maxDrain)
+	}
+
+	newBody, contentLength, err := c.attemptBody()
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+
+	start := c.client.now()
+	ctx := context.WithValue(c.req.Context(), contextKeyAttempt{}, attemptInfo{
+		number:          c.attempts,
+		start:           start,
+		previousLatency: c.latency,
+		request:         c.req,
+		now:             c.client.now,
+	})
+	ctx, cancel := c.client.attemptContext(ctx)
+	// mutations of the request of the attempt, for instance by the Conditioner, must not leak into the next one
+	attemptReq := c.req.Clone(ctx)
+	if c.authHeader != nil {
+		attemptReq.Header = c.authHeader.Clone()
+	}
+	attemptReq.Body = newBody() // so we can re-read the request body over again
+	if contentLength >= 0 {
+		attemptReq.ContentLength = contentLength
+	}
+	c.client.propagateDeadline(attemptReq)
+	if c.primary != nil {
+		rewriteToPrimary(attemptReq, c.primary)
+	}
+	c.addIdempotencyKey(attemptReq)
+
+	c.sent, c.attemptReq = true, attemptReq
+	c.client.hooks.attemptStart(attemptReq, c.attempts)
+	c.client.publish(RetryEvent{Type: AttemptStarted, Request: c.req, Attempt: c.attempts})
+	if c.client.endpointSelector != nil {
+		var err error
+		if attemptReq, err = c.selectEndpoint(attemptReq); err != nil {
+			cancel()
+			return c.client.categorizeRequestError(c.req, err)
+		}
 	}
 
-	switch specificErr := reqErr.(type) {
-	case net.Error:
-		switch {
-		case specificErr.Timeout(), specificErr.Temporary():
+	httpClient := c.client.httpClient
+	if c.forceHTTP1 {
+		if http1Client, ok := c.client.http1Client(); ok {
+			httpClient = http1Client
+		}
+	}
+	if c.client.endpointSelector != nil {
+		httpClient = c.client.endpointClient(httpClient)
+	}
+	httpClient = c.withRedirectsAndCookies(httpClient)
+
+	var reqErr error
+	if c.client.maxHedges > 0 && isIdempotent(c.req.Method) && !c.unreplayable {
+		c.resp, reqErr = c.client.hedge(httpClient, attemptReq, newBody)
+	} else {
+		c.resp, reqErr = httpClient.Do(attemptReq)
+	}
+	c.latency = c.client.now().Sub(start)
+	if reqErr != nil {
+		cancel()
+	} else if c.client.attemptTimeout > 0 {
+		// the body is still to be read within the timeout of the attempt
+		c.resp.Body = &cancelOnClose{ReadCloser: c.resp.Body, cancel: cancel}
+	}
+	c.log[len(c.log)-1].Start, c.log[len(c.log)-1].Duration = start, c.latency
+	if c.client.metrics != nil {
+		c.client.metrics.ObserveLatency(c.latency)
+	}
+	if reqErr != nil {
+		if categorized, ok := c.categorizeSourceError(reqErr); ok {
+			return categorized
+		}
+		if c.client.endpointSelector != nil && c.endpointFailed(reqErr) {
 			return reqErr
-		default:
-			return backoff.Permanent(reqErr)
 		}
-	default:
-		return backoff.Permanent(reqErr)
+		if c.client.http1Fallback && !c.forceHTTP1 && isHTTP2Error(reqErr) {
+			c.forceHTTP1 = true
+			return reqErr
+		}
+		return c.client.categorizeRequestError(c.req, reqErr)
 	}
+
+	if c.client.retryOnBodyReadError && isIdempotent(c.req.Method) {
+		if _, err := peekBody(c.resp); err != nil {
+			return errors.Wrap(err, "reading response body")
+		}
+	}
+
+	if err := c.divergence.observe(c.req, c.attempts, c.resp); err != nil {
+		return err
+	}
+
+	restoreBody := func() {}
+	if c.client.maxBufferedResponse > 0 {
+		if restoreBody, err = lendBody(c.resp, c.client.maxBufferedResponse); err != nil {
+			return errors.Wrap(err, "reading response body")
+		}
+	}
+	advice := c.conditioner.advise(c.resp)
+	restoreBody()
+	if !advice.Retry && !advice.Permanent {
+		if c.backoffer.learned != nil {
+			c.backoffer.learned.forget(c.backoffer.host)
+		}
+		return nil
+	}
+
+	reqErr = c.backoffer.suggest(advice.error())
+
+	if failover, ok := reqErr.(*failoverError); ok {
+		c.primary = failover.primary
+	}
+
+	if advice.Retry && !advice.Permanent {
+		return reqErr
+	}
+
+	return backoff.Permanent(reqErr)
+}
+
+// host returns the host the attempts of the call are sent to.
+func (c *call) host() string {
+	if c.primary != nil {
+		return c.primary.Host
+	}
+	return c.req.URL.Host
+}
+
+func (c *call) takeFingerprint() (err error) {
+	if c.client.checkFingerprint {
+		c.fingerprint, err = fingerprint(c.fingerprinted(), c.getBody())
+	}
+	return err
+}
+
+func readBody(body io.ReadCloser) ([]byte, error) {
+	defer body.Close()
+	return ioutil.ReadAll(body)
 }
 
 // OK signals that no error occurred and we do not need to retry
@@ -107,6 +541,14 @@ func OK() (bool, error) {
 	return false, nil
 }
 
+// Abstain signals that a Conditioner does not recognize the response and leaves the decision to others, see
+// FirstDecisive. A client whose Conditioner abstains accepts the response.
+func Abstain() (bool, error) {
+	return false, errAbstain
+}
+
+var errAbstain = errors.New("conditioner abstained")
+
 // RetriableError signals that a retriable error ocurred
 func RetriableError(msg string) (bool, error) {
 	return true, errors.New(msg)
@@ -130,9 +572,44 @@ func PermanentErrorf(msg string, values ...interface{}) (bool, error) {
 // NewDefaultBackoffClient5XX retries requests if they result in 5XXs and accepts them if they result in 2XXs.
 // If they are neither they return an error and retry no longer.
 func NewDefaultBackoffClient5XX(httpClient http.Client) Client {
-	return NewBackoffClient(httpClient, backoff.NewExponentialBackOff(), func(resp *http.Response) (bool, error) {
-		if resp.StatusCode >= 500 && resp.StatusCode < 600 {
-			return RetriableErrorf("bad status code %d", resp.StatusCode)
+	return NewBackoffClient(httpClient, backoff.NewExponentialBackOff(), retryOn5XX)
+}
+
+func retryOn5XX(resp *http.Response) (bool, error) {
+	if resp.StatusCode >= 500 && resp.StatusCode < 600 {
+		return RetriableErrorf("bad status code %d", resp.StatusCode)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return OK()
+	}
+
+	return PermanentErrorf("bad status code %d", resp.StatusCode)
+}
+
+// NewDefaultBackoffClient retries requests if they result in 5XXs or 429 Too Many Requests, and accepts them
+// if they result in 2XXs. If they are neither they return an error and retry no longer. Being configured only
+// with options, it is the constructor to start from: see WithConditioner and WithBackoff to replace the defaults.
+func NewDefaultBackoffClient(httpClient http.Client, opts ...Option) *BackoffClient {
+	return NewBackoffClient(httpClient, backoff.NewExponentialBackOff(), retryOn429Or5XX, opts...)
+}
+
+func retryOn429Or5XX(resp *http.Response) (bool, error) {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return RetriableErrorf("bad status code %d", resp.StatusCode)
+	}
+	return retryOn5XX(resp)
+}
+
+// RetryOnStatus returns a Conditioner that retries responses with one of the given status codes and accepts
+// other 2XXs. Everything else results in an unretriable error. It lets you pick the retriable statuses, for
+// instance RetryOnStatus(http.StatusTooManyRequests, http.StatusServiceUnavailable).
+func RetryOnStatus(codes ...int) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		for _, code := range codes {
+			if resp.StatusCode == code {
+				return RetriableErrorf("bad status code %d", resp.StatusCode)
+			}
 		}
 
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
@@ -140,12 +617,13 @@ func NewDefaultBackoffClient5XX(httpClient http.Client) Client {
 		}
 
 		return PermanentErrorf("bad status code %d", resp.StatusCode)
-	})
+	}
 }
 
-// Attempts can be used to tell how many attempts a response took for its execution.
+// Attempts can be used to tell how many attempts a response took for its execution. It returns 0 for a nil
+// response, as returned when a request fails without any response.
 func Attempts(resp *http.Response) int {
-	attempts, _ := resp.Request.Context().Value(contextKeyAttempts{}).(int)
+	attempts, _ := valueOf(resp, contextKeyAttempts{}).(int)
 	return attempts
 }
 
@@ -154,3 +632,11 @@ func addAttemptsToRequest(resp *http.Response, attempts int) {
 		resp.Request = resp.Request.WithContext(context.WithValue(resp.Request.Context(), contextKeyAttempts{}, attempts))
 	}
 }
+
+// valueOf returns the value for key in the context of the request of resp, or nil if there is no request.
+func valueOf(resp *http.Response, key interface{}) interface{} {
+	if resp == nil || resp.Request == nil {
+		return nil
+	}
+	return resp.Request.Context().Value(key)
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/cenkalti/backoff"
 	"github.com/stretchr/testify/assert"
@@ -12,13 +14,16 @@ import (
 
 var backoffer = backoff.NewExponentialBackOff()
 
+// noSleep makes retries with backoffer immediate.
+var noSleep = WithSleepFunc(func(time.Duration) {})
+
 func TestRequestRetries(t *testing.T) {
 	client := NewBackoffClient(http.Client{}, backoffer, func(resp *http.Response) (bool, error) {
 		if resp.StatusCode == 500 {
 			return true, errors.New("bad")
 		}
 		return false, nil
-	})
+	}, noSleep)
 
 	var requestCount int
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
@@ -87,3 +92,61 @@ func TestRequestReturnsErrImmediately(t *testing.T) {
 	assert.Equal(t, 1, requestCount)
 	assert.EqualError(t, err, "bad")
 }
+
+func TestUnreachableHostFailsWithoutResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), retryOn5XX,
+		WithRetriableSyscallErrors(syscall.ECONNREFUSED))
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, err := client.Do(req)
+
+	assert.Nil(t, resp)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "connection refused")
+	}
+	assert.Equal(t, 0, Attempts(resp))
+	assert.Equal(t, int64(0), DrainedBytes(resp))
+	assert.Empty(t, AttemptLog(resp))
+}
+
+func TestDefaultBackoffClientRetries429(t *testing.T) {
+	var requestCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer ts.Close()
+
+	client := NewDefaultBackoffClient(http.Client{}, WithBackoff(&backoff.ZeroBackOff{}))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, Attempts(resp))
+}
+
+func TestRetryOnStatus(t *testing.T) {
+	conditioner := RetryOnStatus(http.StatusTooManyRequests, http.StatusServiceUnavailable)
+
+	for code, expected := range map[int]struct {
+		retry bool
+		err   bool
+	}{
+		http.StatusTooManyRequests:     {true, true},
+		http.StatusServiceUnavailable:  {true, true},
+		http.StatusInternalServerError: {false, true},
+		http.StatusNotFound:            {false, true},
+		http.StatusNoContent:           {false, false},
+	} {
+		shouldRetry, err := conditioner(&http.Response{StatusCode: code})
+		assert.Equal(t, expected.retry, shouldRetry, code)
+		assert.Equal(t, expected.err, err != nil, code)
+	}
+}
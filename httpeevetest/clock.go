@@ -0,0 +1,51 @@
+// Package httpeevetest provides utilities for testing retries with httpeeve quickly and deterministically: a
+// fake Clock that skips the waiting between attempts, and servers and transports that answer with a scripted
+// sequence of responses and errors while recording the requests they got.
+package httpeevetest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a fake httpeeve.Clock. Time stands still until Sleep or Advance move it forward, and Sleep returns
+// right away. Plug it into a client with httpeeve.WithClock. It is safe for concurrent use.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// NewClock creates a Clock that starts at start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep advances the clock by d and records it, without waiting.
+func (c *Clock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+}
+
+// Advance moves the clock forward by d, without recording it as a sleep.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Sleeps returns the durations passed to Sleep so far, in order.
+func (c *Clock) Sleeps() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.sleeps...)
+}
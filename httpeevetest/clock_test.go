@@ -0,0 +1,51 @@
+package httpeevetest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/motain/httpeeve"
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Date(2019, time.May, 2, 10, 0, 0, 0, time.UTC)
+
+func TestClock(t *testing.T) {
+	clock := NewClock(epoch)
+	clock.Sleep(time.Second)
+	clock.Advance(time.Minute)
+	clock.Sleep(2 * time.Second)
+
+	assert.Equal(t, epoch.Add(time.Minute+3*time.Second), clock.Now())
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, clock.Sleeps())
+}
+
+func TestClockSkipsBackoff(t *testing.T) {
+	client := NewScriptedClient(Status(http.StatusServiceUnavailable), Status(http.StatusServiceUnavailable), Status(http.StatusOK))
+	clock := NewClock(epoch)
+	retryOn5XX := httpeeve.RetryOnStatusRange(500, 599)
+	c := httpeeve.NewBackoffClient(client.HTTPClient(), backoff.NewConstantBackOff(time.Hour), retryOn5XX, httpeeve.WithClock(clock))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	resp, err := c.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, httpeeve.Attempts(resp))
+	assert.Equal(t, []time.Duration{time.Hour, time.Hour}, clock.Sleeps())
+	assert.Equal(t, epoch.Add(2*time.Hour), clock.Now())
+}
+
+func TestClockEnforcesMaxElapsedTime(t *testing.T) {
+	client := NewScriptedClient(Status(http.StatusServiceUnavailable))
+	clock := NewClock(epoch)
+	c := httpeeve.NewBackoffClient(client.HTTPClient(), backoff.NewConstantBackOff(time.Hour), httpeeve.RetryOnStatusRange(500, 599),
+		httpeeve.WithClock(clock), httpeeve.WithMaxElapsedTime(3*time.Hour))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	_, err := c.Do(req)
+
+	assert.Error(t, err)
+	assert.Equal(t, 4, client.Attempts())
+}
@@ -0,0 +1,163 @@
+package httpeevetest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Step is one scripted answer to an attempt: either a response with StatusCode, Header and Body, or Err.
+type Step struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+	// Err fails the attempt. A ScriptedClient returns it from RoundTrip, while a ScriptedServer cannot send
+	// errors and closes the connection without a response instead.
+	Err error
+}
+
+// Status is a Step answering with an empty response with statusCode.
+func Status(statusCode int) Step {
+	return Step{StatusCode: statusCode}
+}
+
+// Fail is a Step failing with err.
+func Fail(err error) Step {
+	return Step{Err: err}
+}
+
+// Request is a request recorded by a ScriptedServer or a ScriptedClient.
+type Request struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// script hands out its steps in order, repeating the last one once it ran out, and records the requests it got.
+type script struct {
+	mu       sync.Mutex
+	steps    []Step
+	requests []Request
+}
+
+func (s *script) next(req *http.Request) Step {
+	var body []byte
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, Request{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone(), Body: body})
+
+	i := len(s.requests) - 1
+	if i >= len(s.steps) {
+		i = len(s.steps) - 1
+	}
+	if i < 0 {
+		return Status(http.StatusOK)
+	}
+	return s.steps[i]
+}
+
+func (s *script) recorded() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ScriptedServer is an httptest.Server that answers the nth request with the nth step of its script, and every
+// request after the script ran out with the last step. Without steps it answers with 200 OK.
+type ScriptedServer struct {
+	*httptest.Server
+	script *script
+}
+
+// NewScriptedServer starts a ScriptedServer, which the caller should Close when finished.
+func NewScriptedServer(steps ...Step) *ScriptedServer {
+	s := &ScriptedServer{script: &script{steps: steps}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *ScriptedServer) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	step := s.script.next(req)
+	if step.Err != nil {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+		return
+	}
+
+	for name, values := range step.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(step.StatusCode)
+	_, _ = w.Write([]byte(step.Body))
+}
+
+// Attempts returns how many requests the server got.
+func (s *ScriptedServer) Attempts() int {
+	return len(s.script.recorded())
+}
+
+// Requests returns the requests the server got, in order.
+func (s *ScriptedServer) Requests() []Request {
+	return s.script.recorded()
+}
+
+// ScriptedClient is an http.RoundTripper that answers the nth request with the nth step of its script, and every
+// request after the script ran out with the last step, without touching the network. Without steps it answers
+// with 200 OK.
+type ScriptedClient struct {
+	script *script
+}
+
+// NewScriptedClient creates a ScriptedClient.
+func NewScriptedClient(steps ...Step) *ScriptedClient {
+	return &ScriptedClient{script: &script{steps: steps}}
+}
+
+// RoundTrip answers req with the next step.
+func (c *ScriptedClient) RoundTrip(req *http.Request) (*http.Response, error) {
+	step := c.script.next(req)
+	if step.Err != nil {
+		return nil, step.Err
+	}
+
+	header := step.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", step.StatusCode, http.StatusText(step.StatusCode)),
+		StatusCode:    step.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewBufferString(step.Body)),
+		ContentLength: int64(len(step.Body)),
+		Request:       req,
+	}, nil
+}
+
+// HTTPClient returns an "net/http".Client sending its requests through c, to be passed to httpeeve.NewClient.
+func (c *ScriptedClient) HTTPClient() http.Client {
+	return http.Client{Transport: c}
+}
+
+// Attempts returns how many requests the client got.
+func (c *ScriptedClient) Attempts() int {
+	return len(c.script.recorded())
+}
+
+// Requests returns the requests the client got, in order.
+func (c *ScriptedClient) Requests() []Request {
+	return c.script.recorded()
+}
@@ -0,0 +1,74 @@
+package httpeevetest
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/motain/httpeeve"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptedClient(t *testing.T) {
+	refused := errors.New("connection refused")
+	client := NewScriptedClient(
+		Fail(refused),
+		Step{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"1"}}},
+		Step{StatusCode: http.StatusOK, Body: "done"},
+	)
+	transport := client.HTTPClient()
+
+	var statuses []int
+	for i := 0; i < 4; i++ {
+		req, _ := http.NewRequest(http.MethodPost, "http://example.com/items", strings.NewReader("item"))
+		resp, err := transport.Do(req)
+		if err != nil {
+			assert.True(t, errors.Is(err, refused))
+			statuses = append(statuses, 0)
+			continue
+		}
+		statuses = append(statuses, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, []int{0, http.StatusTooManyRequests, http.StatusOK, http.StatusOK}, statuses, "the last step repeats")
+	assert.Equal(t, 4, client.Attempts())
+	assert.Equal(t, Request{Method: http.MethodPost, URL: "http://example.com/items", Header: http.Header{}, Body: []byte("item")}, client.Requests()[0])
+}
+
+func TestScriptedServer(t *testing.T) {
+	server := NewScriptedServer(Fail(errors.New("reset")), Status(http.StatusBadGateway), Step{StatusCode: http.StatusOK, Body: "done"})
+	defer server.Close()
+
+	client := httpeeve.NewClient(http.Client{}, httpeeve.RetryOnStatusRange(500, 599), httpeeve.WithClock(NewClock(epoch)))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/status", nil)
+	resp, err := client.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	assert.Equal(t, "done", string(body))
+	assert.Equal(t, 3, server.Attempts())
+	for _, recorded := range server.Requests() {
+		assert.Equal(t, "/status", recorded.URL)
+	}
+}
+
+func TestScriptedServerWithoutSteps(t *testing.T) {
+	server := NewScriptedServer()
+	defer server.Close()
+
+	client := httpeeve.NewClient(http.Client{Timeout: time.Second}, httpeeve.RetryOnStatusRange(500, 599))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
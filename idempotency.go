@@ -0,0 +1,62 @@
+package httpeeve
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// WithIdempotencyKey makes it safe to retry requests with methods that are not idempotent, such as POST: every
+// such request is sent with a random UUID in header, like "Idempotency-Key", and the same UUID is sent on all of
+// its attempts, so that the server can recognize attempts it already processed. A request that carries the
+// header already keeps its key. As the server deduplicates them, these requests are retried even though
+// WithIdempotentOnly is in effect, but never if their method is declared in NonRetriableMethods.
+func WithIdempotencyKey(header string) Option {
+	return func(c *BackoffClient) {
+		c.idempotencyKeyHeader = header
+	}
+}
+
+// takeIdempotencyKey picks the key that is sent on all attempts of the call, if it needs one.
+func (c *call) takeIdempotencyKey() error {
+	header := c.client.idempotencyKeyHeader
+	if header == "" || isIdempotent(c.req.Method) {
+		return nil
+	}
+
+	if c.idempotencyKey = c.req.Header.Get(header); c.idempotencyKey != "" {
+		return nil
+	}
+
+	key, err := newUUID()
+	if err != nil {
+		return fmt.Errorf("generating idempotency key: %w", err)
+	}
+	c.idempotencyKey = key
+	return nil
+}
+
+// addIdempotencyKey sets the key of the call on the request of an attempt, without touching the headers
+// of the caller.
+func (c *call) addIdempotencyKey(req *http.Request) {
+	if c.idempotencyKey == "" || req.Header.Get(c.client.idempotencyKeyHeader) == c.idempotencyKey {
+		return
+	}
+
+	req.Header = req.Header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set(c.client.idempotencyKeyHeader, c.idempotencyKey)
+}
+
+// newUUID returns a random, version 4 UUID.
+func newUUID() (string, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return "", err
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]), nil
+}
@@ -0,0 +1,72 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+		if len(keys)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotencyKey("Idempotency-Key"))
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("{}"))
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, Attempts(resp))
+		assert.Empty(t, req.Header.Get("Idempotency-Key"), "the request of the caller is left alone")
+	}
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	assert.Regexp(t, uuid, keys[0])
+	assert.Equal(t, []string{keys[0], keys[0], keys[0]}, keys[:3], "all attempts share a key")
+	assert.Equal(t, []string{keys[3], keys[3], keys[3]}, keys[3:], "all attempts share a key")
+	assert.NotEqual(t, keys[0], keys[3], "every request has its own key")
+}
+
+func TestIdempotencyKeyOfCallerIsKept(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotencyKey("Idempotency-Key"))
+
+	post, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	post.Header.Set("Idempotency-Key", "order-42")
+	_, err := client.Do(post)
+	assert.NoError(t, err)
+
+	get, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err = client.Do(get)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"order-42", ""}, keys, "idempotent methods need no key")
+}
+
+func TestIdempotencyKeyRespectsNonRetriableMethods(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotencyKey("Idempotency-Key"), NonRetriableMethods(http.MethodPatch))
+
+	post, _ := http.NewRequest(http.MethodPost, "http://localhost", nil)
+	ok, _ := client.CanRetry(post)
+	assert.True(t, ok)
+
+	patch, _ := http.NewRequest(http.MethodPatch, "http://localhost", nil)
+	ok, _ = client.CanRetry(patch)
+	assert.False(t, ok)
+}
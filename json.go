@@ -0,0 +1,113 @@
+package httpeeve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxJSONResponse is the size limit of the responses decoded by DoJSON, GetJSON and PostJSONInto,
+// unless WithMaxJSONResponse sets another one.
+const DefaultMaxJSONResponse = 10 << 20
+
+// WithMaxJSONResponse limits the size of the responses decoded by DoJSON, GetJSON and PostJSONInto to maxBytes,
+// instead of DefaultMaxJSONResponse. Larger responses fail to decode.
+func WithMaxJSONResponse(maxBytes int64) Option {
+	return func(c *BackoffClient) {
+		c.maxJSONResponse = maxBytes
+	}
+}
+
+// PostJSON marshals v to JSON and posts it to url. The request body can be replayed, so it is sent in full
+// on every attempt. As POST is not idempotent, it is only retried by clients created with
+// WithIdempotentOnly(false).
+func (c *BackoffClient) PostJSON(ctx context.Context, url string, v interface{}) (*http.Response, error) {
+	req, err := newJSONRequest(ctx, http.MethodPost, url, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(req)
+}
+
+// GetJSON gets url and decodes the JSON response into out, see DoJSON.
+func (c *BackoffClient) GetJSON(ctx context.Context, url string, out interface{}) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.DoJSON(req, out)
+}
+
+// PostJSONInto posts in to url like PostJSON, and decodes the JSON response into out, see DoJSON.
+func (c *BackoffClient) PostJSONInto(ctx context.Context, url string, in, out interface{}) (*http.Response, error) {
+	req, err := newJSONRequest(ctx, http.MethodPost, url, in)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.DoJSON(req, out)
+}
+
+// DoJSON sends req like Do, asking for JSON, and decodes the response it accepted into out, unless out is nil or
+// the body is empty. The response is returned with its body read and closed. Responses without a 2XX status code
+// are not decoded but result in an error, as do responses that are not valid JSON or exceed the size limit of
+// WithMaxJSONResponse. These are not retried: a response that is accepted is not sent again.
+func (c *BackoffClient) DoJSON(req *http.Request, out interface{}) (*http.Response, error) {
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp, fmt.Errorf("bad status code %d", resp.StatusCode)
+	}
+
+	maxBytes := c.maxJSONResponse
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxJSONResponse
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return resp, errors.Wrap(err, "reading response body")
+	}
+	if int64(len(body)) > maxBytes {
+		return resp, fmt.Errorf("response body exceeds %d bytes", maxBytes)
+	}
+
+	if out != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, out); err != nil {
+			return resp, errors.Wrap(err, "decoding response body")
+		}
+	}
+
+	return resp, nil
+}
+
+// newJSONRequest creates a request with v marshaled to JSON as its body, which can be replayed.
+func newJSONRequest(ctx context.Context, method, url string, v interface{}) (*http.Request, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling request body")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
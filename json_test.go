@@ -0,0 +1,127 @@
+package httpeeve
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPayload struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestPostJSONReplaysBody(t *testing.T) {
+	var received []testPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+		var payload testPayload
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&payload))
+		received = append(received, payload)
+
+		if len(received) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotentOnly(false))
+
+	resp, err := client.PostJSON(context.Background(), server.URL, testPayload{Name: "gizmo", Count: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, []testPayload{{"gizmo", 3}, {"gizmo", 3}}, received)
+}
+
+func TestPostJSONMarshalError(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	_, err := client.PostJSON(context.Background(), "http://localhost", make(chan int))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "marshaling request body")
+}
+
+func TestGetJSON(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "application/json", req.Header.Get("Accept"))
+		requestCount++
+		if requestCount == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "gizmo", "count": 3}`))
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	var out testPayload
+	resp, err := client.GetJSON(context.Background(), server.URL, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+	assert.Equal(t, testPayload{"gizmo", 3}, out)
+}
+
+func TestPostJSONInto(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var payload testPayload
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&payload))
+		payload.Count++
+		w.WriteHeader(http.StatusCreated)
+		assert.NoError(t, json.NewEncoder(w).Encode(payload))
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	var out testPayload
+	_, err := client.PostJSONInto(context.Background(), server.URL, testPayload{Name: "gizmo", Count: 3}, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, testPayload{"gizmo", 4}, out)
+}
+
+func TestDoJSONErrors(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		switch req.URL.Path {
+		case "/invalid":
+			_, _ = w.Write([]byte(`{"name": `))
+		case "/large":
+			_, _ = w.Write([]byte(`{"name": "a name that is much too long"}`))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/redirect":
+			w.WriteHeader(http.StatusNotModified)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, RetryOnStatusRange(500, 599), WithMaxJSONResponse(16))
+
+	for path, expected := range map[string]string{
+		"/invalid":  "decoding response body: unexpected end of JSON input",
+		"/large":    "response body exceeds 16 bytes",
+		"/redirect": "bad status code 304",
+	} {
+		requestCount = 0
+		var out testPayload
+		_, err := client.GetJSON(context.Background(), server.URL+path, &out)
+		assert.EqualError(t, err, expected, path)
+		assert.Equal(t, 1, requestCount, "%s is not retried", path)
+	}
+
+	out := testPayload{Name: "unchanged"}
+	_, err := client.GetJSON(context.Background(), server.URL+"/empty", &out)
+	assert.NoError(t, err)
+	assert.Equal(t, testPayload{Name: "unchanged"}, out)
+}
@@ -0,0 +1,64 @@
+package httpeeve
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+var (
+	// ErrMaxAttemptsExceeded is matched with errors.Is by the RetryError of a request that was given up on because
+	// it used up the attempts allowed by WithMaxAttempts, WithMaxRetries or its Policy.
+	ErrMaxAttemptsExceeded = errors.New("httpeeve: max attempts exceeded")
+	// ErrMaxElapsedTimeExceeded is matched with errors.Is by the RetryError of a request that was given up on
+	// because the next retry would have been due after the time allowed by WithMaxElapsedTime.
+	ErrMaxElapsedTimeExceeded = errors.New("httpeeve: max elapsed time exceeded")
+)
+
+// WithMaxElapsedTime limits how long the client keeps retrying a request, on top of the limits of the backoff
+// and the context of the request. A retry that would be due more than maxElapsed after the request was first
+// sent is not made. A limit of 0 or less leaves it to the backoff.
+func WithMaxElapsedTime(maxElapsed time.Duration) Option {
+	return func(c *BackoffClient) {
+		c.maxElapsed = maxElapsed
+	}
+}
+
+// limitBackOff stops retrying once maxRetries retries were made or the next one would be due after maxElapsed,
+// and remembers which limit it ran into. A negative maxRetries and a maxElapsed of 0 or less disable the limit.
+type limitBackOff struct {
+	backoff.BackOff
+	maxRetries int
+	maxElapsed time.Duration
+	now        func() time.Time
+
+	start    time.Time
+	retries  int
+	exceeded error
+}
+
+func (b *limitBackOff) Reset() {
+	b.BackOff.Reset()
+	b.start, b.retries, b.exceeded = b.now(), 0, nil
+}
+
+func (b *limitBackOff) NextBackOff() time.Duration {
+	if b.maxRetries >= 0 && b.retries >= b.maxRetries {
+		b.exceeded = ErrMaxAttemptsExceeded
+		return backoff.Stop
+	}
+
+	next := b.BackOff.NextBackOff()
+	if next == backoff.Stop {
+		return next
+	}
+
+	if b.maxElapsed > 0 && b.now().Sub(b.start)+next > b.maxElapsed {
+		b.exceeded = ErrMaxElapsedTimeExceeded
+		return backoff.Stop
+	}
+
+	b.retries++
+	return next
+}
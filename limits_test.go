@@ -0,0 +1,75 @@
+package httpeeve
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxAttemptsExceeded(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewClient(http.Client{}, retryOn5XX, WithBackoff(&backoff.ZeroBackOff{}), WithMaxAttempts(2))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	resp, err := client.Do(req)
+
+	assert.True(t, errors.Is(err, ErrMaxAttemptsExceeded))
+	assert.False(t, errors.Is(err, ErrMaxElapsedTimeExceeded))
+	var retryErr *RetryError
+	if assert.True(t, errors.As(err, &retryErr)) {
+		assert.Equal(t, 2, retryErr.Attempts)
+		assert.Same(t, resp, retryErr.Response)
+		assert.Equal(t, http.StatusServiceUnavailable, retryErr.Response.StatusCode)
+	}
+}
+
+func TestMaxAttemptsNotExceededWhenTheBackoffStops(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewClient(http.Client{}, retryOn5XX,
+		WithBackoff(backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1)), WithMaxAttempts(5))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	resp, err := client.Do(req)
+
+	assert.Equal(t, 2, Attempts(resp))
+	assert.False(t, errors.Is(err, ErrMaxAttemptsExceeded))
+	var retryErr *RetryError
+	assert.True(t, errors.As(err, &retryErr))
+}
+
+func TestWithMaxElapsedTime(t *testing.T) {
+	var requestCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	now := pinnedNow
+	client := NewClient(http.Client{}, retryOn5XX,
+		WithBackoff(backoff.NewConstantBackOff(time.Minute)),
+		WithMaxElapsedTime(150*time.Second),
+		WithNow(func() time.Time { return now }),
+		WithSleepFunc(func(d time.Duration) { now = now.Add(d) }))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	resp, err := client.Do(req)
+
+	assert.True(t, errors.Is(err, ErrMaxElapsedTimeExceeded))
+	assert.EqualError(t, err, "bad status code 503")
+	assert.Equal(t, 3, requestCount, "a third retry would be due after 3 minutes")
+	assert.Equal(t, 3, Attempts(resp))
+}
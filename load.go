@@ -0,0 +1,38 @@
+package httpeeve
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ServerLoadHeader is the header ScaleByServerLoad reads the load of a server from, as a number from 0.0 to 1.0.
+const ServerLoadHeader = "X-Server-Load"
+
+// maxLoadMultiplier is what the backoff interval is multiplied by when a server reports full load.
+const maxLoadMultiplier = 5
+
+// ScaleByServerLoad wraps conditioner to be a cooperative client: whenever conditioner decides to retry a
+// response that reports its server's load in the ServerLoadHeader, the backoff interval is scaled up in
+// proportion to that load, up to five times the interval at full load. The scaled interval is clamped to maxWait.
+func ScaleByServerLoad(conditioner Conditioner, maxWait time.Duration) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		shouldRetry, err := conditioner(resp)
+		if err == nil || !shouldRetry {
+			return shouldRetry, err
+		}
+
+		load, parseErr := strconv.ParseFloat(resp.Header.Get(ServerLoadHeader), 64)
+		if parseErr != nil || load < 0 || load > 1 {
+			return shouldRetry, err
+		}
+
+		return true, &delaySuggestion{err: err, adjust: func(next time.Duration, _ time.Time) time.Duration {
+			scaled := time.Duration(float64(next) * (1 + load*(maxLoadMultiplier-1)))
+			if scaled > maxWait {
+				return maxWait
+			}
+			return scaled
+		}}
+	}
+}
@@ -0,0 +1,48 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleByServerLoad(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount%2 == 1 {
+			w.Header().Set(ServerLoadHeader, req.URL.Query().Get("load"))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(10*time.Millisecond), ScaleByServerLoad(retryOn5XX, 40*time.Millisecond), WithEvents(10))
+
+	retryDelay := func(load string) time.Duration {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"?load="+load, nil)
+		_, err := client.Do(req)
+		assert.NoError(t, err)
+
+		for len(client.Events()) > 0 {
+			if event := <-client.Events(); event.Type == Retrying {
+				return event.Delay
+			}
+		}
+		return 0
+	}
+
+	low, high := retryDelay("0.1"), retryDelay("0.9")
+	assert.Equal(t, 14*time.Millisecond, low)
+	assert.Equal(t, 40*time.Millisecond, high, "the scaled interval is clamped")
+	assert.True(t, high > low)
+
+	other := retryDelay("not-a-number")
+	assert.Equal(t, 10*time.Millisecond, other)
+}
@@ -0,0 +1,122 @@
+package httpeeve
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LogLevel is the severity of a log entry of the client.
+type LogLevel int
+
+const (
+	// LevelDebug is used for every attempt that starts or is done.
+	LevelDebug LogLevel = iota
+	// LevelInfo is used for retries that are scheduled.
+	LevelInfo
+	// LevelWarn is used for requests the client gives up on.
+	LevelWarn
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "unknown"
+	}
+}
+
+// Logger receives the log entries of a client as a message and alternating keys and values, which makes it
+// easy to adapt to structured logging libraries. It must be safe for concurrent use.
+type Logger interface {
+	Log(level LogLevel, msg string, keyvals ...interface{})
+}
+
+// LoggerFunc adapts a function to a Logger.
+type LoggerFunc func(level LogLevel, msg string, keyvals ...interface{})
+
+// Log calls f.
+func (f LoggerFunc) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	f(level, msg, keyvals...)
+}
+
+// NewStdLogger returns a Logger that prints entries to logger, one per line, as in
+// "info retry scheduled attempt=1 method=GET url=http://example.com delay=100ms".
+func NewStdLogger(logger *log.Logger) Logger {
+	return LoggerFunc(func(level LogLevel, msg string, keyvals ...interface{}) {
+		var line strings.Builder
+		fmt.Fprintf(&line, "%s %s", level, msg)
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			fmt.Fprintf(&line, " %v=%v", keyvals[i], keyvals[i+1])
+		}
+		logger.Print(line.String())
+	})
+}
+
+// WithLogger makes the client log every step of its requests to logger, leaving out entries below minLevel.
+// Entries carry the attempt number, method and URL of the request, and, as they apply, the status code, the
+// category of the error, which is "status", "timeout" or "transport", the error itself and the delay until the
+// next attempt. Clients without a logger log nothing.
+func WithLogger(logger Logger, minLevel LogLevel) Option {
+	l := requestLogger{logger: logger, minLevel: minLevel}
+	return WithHooks(Hooks{
+		OnAttemptStart:   l.attemptStart,
+		OnAttemptDone:    l.attemptDone,
+		OnRetryScheduled: l.retryScheduled,
+		OnGiveUp:         l.giveUp,
+	})
+}
+
+type requestLogger struct {
+	logger   Logger
+	minLevel LogLevel
+}
+
+func (l requestLogger) log(level LogLevel, msg string, req *http.Request, attempt int, keyvals ...interface{}) {
+	if level < l.minLevel {
+		return
+	}
+	l.logger.Log(level, msg, append([]interface{}{"attempt", attempt, "method", methodOrGet(req.Method), "url", req.URL}, keyvals...)...)
+}
+
+func (l requestLogger) attemptStart(req *http.Request, attempt int) {
+	l.log(LevelDebug, "attempt started", req, attempt)
+}
+
+func (l requestLogger) attemptDone(req *http.Request, attempt int, resp *http.Response, err error) {
+	var keyvals []interface{}
+	if resp != nil {
+		keyvals = append(keyvals, "status", resp.StatusCode)
+	}
+	if err != nil {
+		keyvals = append(keyvals, "category", errorCategory(resp, err), "error", err)
+	}
+	l.log(LevelDebug, "attempt done", req, attempt, keyvals...)
+}
+
+func (l requestLogger) retryScheduled(req *http.Request, attempt int, delay time.Duration, err error) {
+	l.log(LevelInfo, "retry scheduled", req, attempt, "delay", delay, "error", err)
+}
+
+func (l requestLogger) giveUp(req *http.Request, attempts int, err error) {
+	l.log(LevelWarn, "giving up", req, attempts, "error", err)
+}
+
+// errorCategory tells what went wrong with an attempt that resulted in resp and err.
+func errorCategory(resp *http.Response, err error) string {
+	switch {
+	case resp != nil:
+		return "status"
+	case isTimeout(err):
+		return "timeout"
+	default:
+		return "transport"
+	}
+}
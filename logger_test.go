@@ -0,0 +1,65 @@
+package httpeeve
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	logger := NewStdLogger(log.New(&out, "", 0))
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Millisecond), 1), retryOn5XX,
+		WithLogger(logger, LevelDebug))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.Error(t, err)
+
+	prefix := "attempt=%d method=GET url=" + server.URL
+	assert.Equal(t, []string{
+		"debug attempt started " + fmt.Sprintf(prefix, 1),
+		"debug attempt done " + fmt.Sprintf(prefix, 1) + " status=503 category=status error=bad status code 503",
+		"info retry scheduled " + fmt.Sprintf(prefix, 1) + " delay=1ms error=bad status code 503",
+		"debug attempt started " + fmt.Sprintf(prefix, 2),
+		"debug attempt done " + fmt.Sprintf(prefix, 2) + " status=503 category=status error=bad status code 503",
+		"warn giving up " + fmt.Sprintf(prefix, 2) + " error=bad status code 503",
+	}, strings.Split(strings.TrimSpace(out.String()), "\n"))
+}
+
+func TestWithLoggerMinLevel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	var entries []string
+	logger := LoggerFunc(func(level LogLevel, msg string, keyvals ...interface{}) {
+		entries = append(entries, fmt.Sprintf("%s %s", level, msg))
+	})
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1), retryOn5XX, WithLogger(logger, LevelInfo))
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	_, err := client.Do(req)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"info retry scheduled", "warn giving up"}, entries)
+}
+
+func TestErrorCategory(t *testing.T) {
+	assert.Equal(t, "status", errorCategory(&http.Response{}, errors.New("bad")))
+	assert.Equal(t, "timeout", errorCategory(nil, timeoutError{}))
+	assert.Equal(t, "transport", errorCategory(nil, errors.New("connection refused")))
+}
@@ -0,0 +1,56 @@
+package httpeeve
+
+import (
+	"net/http"
+	"strings"
+)
+
+// isIdempotent tells whether sending a request with method several times has the same effect as sending it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// NonRetriableMethods declares methods whose requests are never retried, for instance PATCH in an API where it is
+// not idempotent. Errors that would otherwise be retried are returned right away, whatever the Conditioner says.
+func NonRetriableMethods(methods ...string) Option {
+	return func(c *BackoffClient) {
+		if c.nonRetriableMethods == nil {
+			c.nonRetriableMethods = map[string]bool{}
+		}
+		for _, method := range methods {
+			c.nonRetriableMethods[strings.ToUpper(method)] = true
+		}
+	}
+}
+
+// WithIdempotentOnly decides whether only requests with idempotent methods are retried, which they are by
+// default: GET, HEAD, OPTIONS, TRACE, PUT and DELETE. Retrying a POST or a PATCH may apply it twice, for
+// instance when the server already processed it before the attempt timed out. The Conditioner still judges
+// responses to other methods, but errors that it would retry are returned right away, as are failed attempts
+// without a response. Pass false to retry any method, except those declared in NonRetriableMethods,
+// or see WithIdempotencyKey to retry them safely.
+func WithIdempotentOnly(idempotentOnly bool) Option {
+	return func(c *BackoffClient) {
+		c.idempotentOnly = idempotentOnly
+	}
+}
+
+// retriesMethod tells whether requests with method may be retried.
+func (c *BackoffClient) retriesMethod(method string) bool {
+	if c.nonRetriableMethods[strings.ToUpper(methodOrGet(method))] {
+		return false
+	}
+	return !c.idempotentOnly || isIdempotent(method) || c.idempotencyKeyHeader != ""
+}
+
+func methodOrGet(method string) string {
+	if method == "" {
+		return http.MethodGet
+	}
+	return method
+}
@@ -0,0 +1,53 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonRetriableMethods(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), retryOn5XX, NonRetriableMethods(http.MethodPatch))
+
+	req, _ := http.NewRequest(http.MethodPatch, ts.URL, nil)
+	resp, err := client.Do(req)
+	assert.EqualError(t, err, "bad status code 503")
+	assert.Equal(t, 1, Attempts(resp))
+
+	req, _ = http.NewRequest(http.MethodPut, ts.URL, nil)
+	resp, err = client.Do(req)
+	assert.EqualError(t, err, "bad status code 503")
+	assert.Equal(t, 3, Attempts(resp))
+}
+
+func TestOnlyIdempotentMethodsAreRetriedByDefault(t *testing.T) {
+	var requestCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), retryOn5XX)
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, nil)
+	resp, err := client.Do(req)
+	assert.EqualError(t, err, "bad status code 500")
+	assert.Equal(t, 1, Attempts(resp))
+	assert.Equal(t, 1, requestCount)
+
+	client = NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), retryOn5XX, WithIdempotentOnly(false))
+
+	req, _ = http.NewRequest(http.MethodPost, ts.URL, nil)
+	resp, err = client.Do(req)
+	assert.EqualError(t, err, "bad status code 500")
+	assert.Equal(t, 3, Attempts(resp))
+}
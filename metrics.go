@@ -0,0 +1,38 @@
+package httpeeve
+
+import (
+	"net/http"
+	"time"
+)
+
+// MetricsSink receives metrics about the requests sent through a BackoffClient. It keeps the package free of any
+// metrics vendor: implement it as an adapter to the backend of your choice. Its methods are called from the
+// goroutines calling Do and must be safe for concurrent use.
+type MetricsSink interface {
+	// IncRetry is called whenever an attempt is about to be retried. Status is the status code of the failed
+	// attempt, or 0 if it did not result in a response.
+	IncRetry(host, method string, status int)
+	// ObserveAttempts is called once per request with the number of attempts it took.
+	ObserveAttempts(n int)
+	// ObserveLatency is called with the latency of every attempt.
+	ObserveLatency(d time.Duration)
+}
+
+// WithMetrics reports metrics about retries, attempts and latencies to sink.
+func WithMetrics(sink MetricsSink) Option {
+	return func(c *BackoffClient) {
+		c.metrics = sink
+	}
+}
+
+func (c *BackoffClient) incRetry(req *http.Request, resp *http.Response) {
+	if c.metrics == nil {
+		return
+	}
+
+	var status int
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	c.metrics.IncRetry(req.URL.Host, req.Method, status)
+}
@@ -0,0 +1,12 @@
+module github.com/motain/httpeeve/metrics
+
+go 1.13
+
+require (
+	github.com/cenkalti/backoff v2.2.1+incompatible
+	github.com/motain/httpeeve v0.0.0
+	github.com/prometheus/client_golang v1.11.0
+	github.com/stretchr/testify v1.7.0
+)
+
+replace github.com/motain/httpeeve => ../
@@ -0,0 +1,107 @@
+// Package metrics exports Prometheus metrics about the requests sent through httpeeve clients. It is a module
+// of its own, so that httpeeve itself does not depend on Prometheus.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/motain/httpeeve"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector records the retries of the clients it is plugged into, see Option. Share one Collector between
+// clients to aggregate their metrics.
+type Collector struct {
+	attempts  prometheus.Histogram
+	retries   *prometheus.CounterVec
+	latency   prometheus.Histogram
+	retryWait prometheus.Counter
+	failures  *prometheus.CounterVec
+}
+
+// NewCollector creates a Collector and registers its metrics on reg:
+//
+//   - httpeeve_attempts_per_request, a histogram of the number of attempts per request
+//   - httpeeve_retries_total, counting retries by host, method and status code, which is 0 without a response
+//   - httpeeve_attempt_duration_seconds, a histogram of the latency of every attempt
+//   - httpeeve_retry_wait_seconds_total, the total time spent waiting between attempts
+//   - httpeeve_failures_total, counting the requests the client gave up on, by kind: "permanent" when the
+//     error was not worth retrying, or "retriable" when the retries ran out
+func NewCollector(reg prometheus.Registerer) (*Collector, error) {
+	c := &Collector{
+		attempts: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "httpeeve_attempts_per_request",
+			Help:    "Number of attempts made per request.",
+			Buckets: prometheus.LinearBuckets(1, 1, 10),
+		}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "httpeeve_retries_total",
+			Help: "Number of retries, by host, method and status code of the failed attempt.",
+		}, []string{"host", "method", "status"}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "httpeeve_attempt_duration_seconds",
+			Help:    "Latency of every attempt.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		retryWait: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "httpeeve_retry_wait_seconds_total",
+			Help: "Total time spent waiting between attempts.",
+		}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "httpeeve_failures_total",
+			Help: "Number of requests given up on, by kind of the final error.",
+		}, []string{"kind"}),
+	}
+
+	for _, collector := range []prometheus.Collector{c.attempts, c.retries, c.latency, c.retryWait, c.failures} {
+		if err := reg.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Option plugs the Collector into a client. It takes the place of any other httpeeve.MetricsSink of the
+// client, while hooks registered with httpeeve.WithHooks keep working.
+func (c *Collector) Option() httpeeve.Option {
+	return func(client *httpeeve.BackoffClient) {
+		httpeeve.WithMetrics(sink{c})(client)
+		httpeeve.WithHooks(httpeeve.Hooks{
+			OnRetryScheduled: func(_ *http.Request, _ int, delay time.Duration, _ error) {
+				c.retryWait.Add(delay.Seconds())
+			},
+			OnGiveUp: func(_ *http.Request, _ int, err error) {
+				c.failures.WithLabelValues(failureKind(err)).Inc()
+			},
+		})(client)
+	}
+}
+
+// failureKind tells whether a request was given up on because retries ran out or because err was permanent.
+func failureKind(err error) string {
+	var retryErr *httpeeve.RetryError
+	if errors.As(err, &retryErr) {
+		return "retriable"
+	}
+	return "permanent"
+}
+
+// sink adapts a Collector to httpeeve.MetricsSink.
+type sink struct {
+	c *Collector
+}
+
+func (s sink) IncRetry(host, method string, status int) {
+	s.c.retries.WithLabelValues(host, method, strconv.Itoa(status)).Inc()
+}
+
+func (s sink) ObserveAttempts(n int) {
+	s.c.attempts.Observe(float64(n))
+}
+
+func (s sink) ObserveLatency(d time.Duration) {
+	s.c.latency.Observe(d.Seconds())
+}
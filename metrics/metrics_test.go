@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/motain/httpeeve"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	reg := prometheus.NewPedanticRegistry()
+	collector, err := NewCollector(reg)
+	assert.NoError(t, err)
+
+	client := httpeeve.NewDefaultBackoffClient(http.Client{},
+		httpeeve.WithBackoff(backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)), collector.Option())
+
+	for _, path := range []string{"/unavailable", "/missing"} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		_, err := client.Do(req)
+		assert.Error(t, err)
+	}
+
+	host := mustHost(t, server.URL)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP httpeeve_retries_total Number of retries, by host, method and status code of the failed attempt.
+# TYPE httpeeve_retries_total counter
+httpeeve_retries_total{host="`+host+`",method="GET",status="503"} 2
+# HELP httpeeve_failures_total Number of requests given up on, by kind of the final error.
+# TYPE httpeeve_failures_total counter
+httpeeve_failures_total{kind="permanent"} 1
+httpeeve_failures_total{kind="retriable"} 1
+# HELP httpeeve_retry_wait_seconds_total Total time spent waiting between attempts.
+# TYPE httpeeve_retry_wait_seconds_total counter
+httpeeve_retry_wait_seconds_total 0
+`), "httpeeve_retries_total", "httpeeve_failures_total", "httpeeve_retry_wait_seconds_total"))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(collector.attempts))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector.latency))
+}
+
+func TestNewCollectorFailsOnDuplicateRegistration(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	_, err := NewCollector(reg)
+	assert.NoError(t, err)
+
+	_, err = NewCollector(reg)
+	assert.Error(t, err)
+}
+
+func mustHost(t *testing.T, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	assert.NoError(t, err)
+	return u.Host
+}
@@ -0,0 +1,60 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSink struct {
+	mu        sync.Mutex
+	retries   []string
+	attempts  []int
+	latencies int
+}
+
+func (s *fakeSink) IncRetry(host, method string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries = append(s.retries, method+" "+host+" "+http.StatusText(status))
+}
+
+func (s *fakeSink) ObserveAttempts(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts = append(s.attempts, n)
+}
+
+func (s *fakeSink) ObserveLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies++
+}
+
+func TestMetrics(t *testing.T) {
+	var requestCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer ts.Close()
+
+	sink := &fakeSink{}
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithMetrics(sink))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+
+	host := mustParseURL(ts.URL).Host
+	assert.Equal(t, []string{"GET " + host + " Bad Gateway", "GET " + host + " Bad Gateway"}, sink.retries)
+	assert.Equal(t, []int{3}, sink.attempts)
+	assert.Equal(t, 3, sink.latencies)
+}
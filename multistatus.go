@@ -0,0 +1,79 @@
+package httpeeve
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type multistatus struct {
+	Responses []struct {
+		Status    string `xml:"status"`
+		Propstats []struct {
+			Status string `xml:"status"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// RetryOn207 returns a Conditioner for WebDAV and batch APIs answering with 207 Multi-Status. The multistatus
+// body is parsed, and the response is retried when the fraction of sub-responses that failed transiently
+// (408, 429 or 5XX) exceeds threshold. The body is restored for the caller. Other responses are treated
+// like in NewDefaultBackoffClient5XX.
+func RetryOn207(threshold float64) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		if resp.StatusCode != http.StatusMultiStatus {
+			return retryOn5XX(resp)
+		}
+
+		body, err := peekBody(resp)
+		if err != nil {
+			return RetriableErrorf("reading multistatus body: %s", err)
+		}
+
+		var ms multistatus
+		if err := xml.Unmarshal(body, &ms); err != nil {
+			return PermanentErrorf("parsing multistatus body: %s", err)
+		}
+
+		if len(ms.Responses) == 0 {
+			return OK()
+		}
+
+		var transient int
+		for _, response := range ms.Responses {
+			failed := isTransientStatusLine(response.Status)
+			for _, propstat := range response.Propstats {
+				failed = failed || isTransientStatusLine(propstat.Status)
+			}
+			if failed {
+				transient++
+			}
+		}
+
+		if float64(transient)/float64(len(ms.Responses)) > threshold {
+			return RetriableErrorf("%d of %d sub-responses failed transiently", transient, len(ms.Responses))
+		}
+
+		return OK()
+	}
+}
+
+// isTransientStatusLine tells whether a status line like "HTTP/1.1 503 Service Unavailable" reports a transient failure.
+func isTransientStatusLine(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return false
+	}
+
+	code, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return false
+	}
+
+	return isTransientStatus(code)
+}
+
+func isTransientStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || (code >= 500 && code < 600)
+}
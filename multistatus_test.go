@@ -0,0 +1,57 @@
+package httpeeve
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func multistatusBody(statuses ...string) string {
+	body := `<?xml version="1.0" encoding="utf-8"?><D:multistatus xmlns:D="DAV:">`
+	for i, status := range statuses {
+		body += fmt.Sprintf(`<D:response><D:href>/item/%d</D:href><D:status>%s</D:status></D:response>`, i, status)
+	}
+	return body + `</D:multistatus>`
+}
+
+func TestRetryOn207(t *testing.T) {
+	bodies := []string{
+		multistatusBody("HTTP/1.1 200 OK", "HTTP/1.1 503 Service Unavailable", "HTTP/1.1 503 Service Unavailable", "HTTP/1.1 404 Not Found"),
+		multistatusBody("HTTP/1.1 200 OK", "HTTP/1.1 200 OK", "HTTP/1.1 503 Service Unavailable", "HTTP/1.1 404 Not Found"),
+	}
+
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(bodies[requestCount-1]))
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, RetryOn207(0.4))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, bodies[1], string(body))
+}
+
+func TestRetryOn207CountsPropstats(t *testing.T) {
+	body := `<multistatus xmlns="DAV:"><response><href>/a</href><propstat><status>HTTP/1.1 200 OK</status></propstat>` +
+		`<propstat><status>HTTP/1.1 500 Internal Server Error</status></propstat></response></multistatus>`
+	resp := &http.Response{StatusCode: http.StatusMultiStatus, Body: ioutil.NopCloser(strings.NewReader(body))}
+
+	shouldRetry, err := RetryOn207(0.5)(resp)
+	assert.True(t, shouldRetry)
+	assert.EqualError(t, err, "1 of 1 sub-responses failed transiently")
+}
@@ -0,0 +1,53 @@
+package httpeeve
+
+import (
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// WithBackoff replaces the exponential backoff of NewClient, which determines the rate and limits of retrying.
+// Every request gets a copy of backoffer, so that concurrent requests do not disturb each other's intervals;
+// backoffs that cannot be copied are shared, see WithBackoffFactory.
+func WithBackoff(backoffer backoff.BackOff) Option {
+	return func(c *BackoffClient) {
+		c.backoffer = backoffer
+		c.backoffFactory = nil
+	}
+}
+
+// WithConditioner replaces the Conditioner the client was created with, which determines when to stop or
+// continue retrying.
+func WithConditioner(conditioner Conditioner) Option {
+	return func(c *BackoffClient) {
+		c.conditioner = conditioner
+	}
+}
+
+// WithMaxRetries limits how often a request is retried, on top of the limits of the backoff. A limit of 0
+// sends every request only once. A request that exhausts the limit fails with a RetryError that matches
+// ErrMaxAttemptsExceeded.
+func WithMaxRetries(maxRetries int) Option {
+	return func(c *BackoffClient) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithMaxAttempts is like WithMaxRetries, but counts the first attempt as well: a limit of 1 sends every request
+// only once.
+func WithMaxAttempts(maxAttempts int) Option {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return WithMaxRetries(maxAttempts - 1)
+}
+
+// WithOnRetry registers a callback that is called right before the client waits to retry a request, with the
+// error of the attempt that failed, its number and the time until the next attempt. It is not called for an
+// attempt that succeeds, or when the client gives up, be it on a permanent error or because the backoff does
+// not allow another retry. For more context, such as the request, see WithEvents.
+func WithOnRetry(onRetry func(err error, attempt int, next time.Duration)) Option {
+	return func(c *BackoffClient) {
+		c.onRetry = onRetry
+	}
+}
@@ -0,0 +1,128 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClientWithOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	var retries []int
+	client := NewClient(http.Client{}, retryOn5XX,
+		WithBackoff(backoff.NewConstantBackOff(time.Millisecond)),
+		WithMaxRetries(2),
+		WithOnRetry(func(err error, attempt int, next time.Duration) {
+			assert.EqualError(t, err, "bad status code 503")
+			assert.Equal(t, time.Millisecond, next)
+			retries = append(retries, attempt)
+		}))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	resp, err := client.Do(req)
+
+	assert.EqualError(t, err, "bad status code 503")
+	assert.Equal(t, 3, Attempts(resp))
+	assert.Equal(t, []int{1, 2}, retries)
+}
+
+func TestNewClientDefaultsToExponentialBackoff(t *testing.T) {
+	client := NewClient(http.Client{}, retryOn5XX)
+
+	_, ok := client.backoffer.(*backoff.ExponentialBackOff)
+	assert.True(t, ok)
+	assert.Equal(t, -1, client.maxRetries)
+}
+
+func TestWithMaxRetriesZeroSendsOnce(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewClient(http.Client{}, retryOn5XX, WithBackoff(&backoff.ZeroBackOff{}), WithMaxRetries(0))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	resp, err := client.Do(req)
+
+	assert.EqualError(t, err, "bad status code 503")
+	assert.Equal(t, 1, Attempts(resp))
+}
+
+func TestOnRetryIsNotCalledWhenGivingUp(t *testing.T) {
+	var requestCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		switch {
+		case r.URL.Path == "/ok":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/permanent" && requestCount > 1:
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	var calls int
+	client := NewClient(http.Client{}, retryOn5XX, WithBackoff(&backoff.ZeroBackOff{}), WithMaxRetries(1),
+		WithOnRetry(func(err error, attempt int, next time.Duration) {
+			calls++
+		}))
+
+	for path, expectedCalls := range map[string]int{"/ok": 0, "/permanent": 1, "/exhausted": 1} {
+		requestCount, calls = 0, 0
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		client.Do(req)
+		assert.Equal(t, expectedCalls, calls, path)
+	}
+}
+
+func TestWithMaxRetriesCapsTotalAttempts(t *testing.T) {
+	var requestCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	exponential := backoff.NewExponentialBackOff()
+	exponential.InitialInterval = time.Millisecond
+	client := NewClient(http.Client{}, retryOn5XX, WithBackoff(exponential), WithMaxRetries(3))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	resp, err := client.Do(req)
+
+	assert.EqualError(t, err, "bad status code 500")
+	assert.Equal(t, 4, requestCount, "the initial attempt and 3 retries")
+	assert.Equal(t, 4, Attempts(resp))
+}
+
+func TestDefaultClientWithConditionerAndMaxAttempts(t *testing.T) {
+	var requestCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	client := NewDefaultBackoffClient(http.Client{},
+		WithConditioner(RetryOnStatus(http.StatusAccepted)),
+		WithBackoff(&backoff.ZeroBackOff{}),
+		WithMaxAttempts(3))
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	resp, err := client.Do(req)
+
+	assert.Error(t, err)
+	assert.Equal(t, 3, Attempts(resp))
+	assert.Equal(t, 3, requestCount)
+}
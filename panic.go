@@ -0,0 +1,81 @@
+package httpeeve
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/cenkalti/backoff"
+)
+
+// PanicError is returned by Do when a Conditioner, a hook or another callback of the client panicked while it
+// was sending a request. The request is not retried. A panic during an attempt or between attempts fails the
+// request with a RetryError whose last error is the PanicError.
+//
+// Every attempt is sent with a copy of the request, so that changes to resp.Request, for instance by the
+// Conditioner, do not carry over to the next attempt. To change the request for all later attempts, such as to
+// renew its credentials, use WithAuthRefresher.
+type PanicError struct {
+	// Value is what was passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+func newPanicError(value interface{}) *PanicError {
+	return &PanicError{Value: value, Stack: debug.Stack()}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic while sending request: %v", e.Value)
+}
+
+// Unwrap returns Value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// panicked turns a panic during an attempt into a permanent error for the attempt to return, recording it on
+// the log entry of the attempt if it got one before logged.
+func (c *call) panicked(value interface{}, logged int) error {
+	panicErr := newPanicError(value)
+	if len(c.log) > logged {
+		c.log[len(c.log)-1].Err = panicErr
+	}
+	c.errors = append(c.errors, panicErr)
+	c.permanent, c.panic = true, panicErr
+	return backoff.Permanent(panicErr)
+}
+
+// recoverPanic turns a panic outside of an attempt, such as in a hook between attempts, into a RetryError for
+// do to return in err, along with no response. The RetryError keeps the errors and the log of the attempts made.
+func (c *call) recoverPanic(resp **http.Response, err *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	panicErr := newPanicError(value)
+	if c.resp != nil && c.resp.Body != nil {
+		c.resp.Body.Close()
+	}
+	c.resp = nil
+	c.errors = append(c.errors, panicErr)
+	c.permanent, c.panic = true, panicErr
+	*resp, *err = nil, c.retryError(nil)
+}
+
+// recoverDo turns a panic before a request got under way, such as in a policy router, into an error for Do to
+// return in err, along with no response.
+func recoverDo(resp **http.Response, err *error) {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	if *resp != nil && (*resp).Body != nil {
+		(*resp).Body.Close()
+	}
+	*resp, *err = nil, newPanicError(value)
+}
@@ -0,0 +1,98 @@
+package httpeeve
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPanickingConditionerFailsRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, func(resp *http.Response) (bool, error) {
+		panic("conditioner bug")
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	var retryErr *RetryError
+	assert.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, 1, retryErr.Attempts)
+	assert.Len(t, retryErr.History, 1)
+
+	var panicErr *PanicError
+	assert.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, "conditioner bug", panicErr.Value)
+	assert.Contains(t, string(panicErr.Stack), "TestPanickingConditionerFailsRequest")
+	assert.Equal(t, error(panicErr), retryErr.History[0].Err)
+	assert.EqualError(t, err, "panic while sending request: conditioner bug")
+}
+
+func TestPanicErrorUnwrapsErrorValues(t *testing.T) {
+	cause := errors.New("boom")
+	assert.True(t, errors.Is(&PanicError{Value: cause}, cause))
+	assert.Nil(t, (&PanicError{Value: "boom"}).Unwrap())
+}
+
+func TestPanickingHookBetweenAttemptsFailsRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3), retryOn5XX, WithHooks(Hooks{
+		OnRetryScheduled: func(*http.Request, int, time.Duration, error) { panic("hook bug") },
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.Nil(t, resp)
+
+	var retryErr *RetryError
+	if !assert.True(t, errors.As(err, &retryErr)) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, retryErr.Attempts)
+	assert.Len(t, retryErr.History, 1)
+	assert.Nil(t, retryErr.Response)
+	assert.EqualError(t, retryErr.Errors[0], "bad status code 503")
+
+	var panicErr *PanicError
+	assert.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, "hook bug", panicErr.Value)
+	assert.Contains(t, string(panicErr.Stack), "TestPanickingHookBetweenAttemptsFailsRequest")
+}
+
+func TestAttemptsGetCopiesOfTheRequest(t *testing.T) {
+	var markers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		markers = append(markers, req.Header.Get("X-Marker"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), func(resp *http.Response) (bool, error) {
+		resp.Request.Header.Set("X-Marker", "leaked")
+		return retryOn5XX(resp)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("X-Marker", "original")
+	resp, err := client.Do(req)
+	assert.Error(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{"original", "original", "original"}, markers)
+	assert.Equal(t, "original", req.Header.Get("X-Marker"))
+}
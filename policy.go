@@ -0,0 +1,115 @@
+package httpeeve
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/cenkalti/backoff"
+)
+
+type contextKeyPolicy struct{}
+
+// Policy is a combination of a backoff and a Conditioner that can be selected per request.
+// A nil field falls back to the one the client was created with. Every request gets a copy of BackOff, as
+// with WithBackoff.
+type Policy struct {
+	BackOff     backoff.BackOff
+	Conditioner Conditioner
+	// MaxAttempts limits the attempts per request like WithMaxAttempts. 0 falls back to the limit of the client.
+	MaxAttempts int
+}
+
+// policyRoute is a Policy for the requests that match.
+type policyRoute struct {
+	match  func(*http.Request) bool
+	policy Policy
+}
+
+// WithNamedPolicies configures policies that requests can select by name with TagRequest, for instance
+// "critical" versus "best-effort". Requests without a tag, or with an unknown one, use the backoff and the
+// Conditioner the client was created with.
+func WithNamedPolicies(policies map[string]Policy) Option {
+	return func(c *BackoffClient) {
+		c.namedPolicies = policies
+	}
+}
+
+// WithPolicyFor configures a policy for the requests that match, for instance those to a given upstream, see
+// MatchHost. It can be used more than once: requests use the policy of the first match, in the order the policies
+// were configured. A policy selected with TagRequest takes precedence, and requests that match nothing use the
+// backoff and the Conditioner the client was created with.
+func WithPolicyFor(match func(*http.Request) bool, policy Policy) Option {
+	return func(c *BackoffClient) {
+		c.policyRoutes = append(c.policyRoutes, policyRoute{match: match, policy: policy})
+	}
+}
+
+// MatchHost matches requests to any of hosts, which are compared to the host of the request URL, including
+// the port if there is one.
+func MatchHost(hosts ...string) func(*http.Request) bool {
+	return func(req *http.Request) bool {
+		for _, host := range hosts {
+			if req.URL.Host == host {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchPathPrefix matches requests whose URL path starts with prefix.
+func MatchPathPrefix(prefix string) func(*http.Request) bool {
+	return func(req *http.Request) bool {
+		return strings.HasPrefix(req.URL.Path, prefix)
+	}
+}
+
+// MatchMethod matches requests with any of methods.
+func MatchMethod(methods ...string) func(*http.Request) bool {
+	return func(req *http.Request) bool {
+		for _, method := range methods {
+			if methodOrGet(req.Method) == method {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// TagRequest returns a copy of ctx that selects the policy with the given name for requests made with it.
+func TagRequest(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKeyPolicy{}, name)
+}
+
+func (c *BackoffClient) policyFor(req *http.Request) Policy {
+	policy := Policy{BackOff: c.newBackOff(), Conditioner: c.conditioner}
+
+	if name, ok := req.Context().Value(contextKeyPolicy{}).(string); ok {
+		if named, ok := c.namedPolicies[name]; ok {
+			return policy.override(named)
+		}
+	}
+
+	for _, route := range c.policyRoutes {
+		if route.match(req) {
+			return policy.override(route.policy)
+		}
+	}
+
+	return policy
+}
+
+// override returns p with the fields that other sets.
+func (p Policy) override(other Policy) Policy {
+	if other.BackOff != nil {
+		p.BackOff = cloneBackOff(other.BackOff)
+	}
+	if other.Conditioner != nil {
+		p.Conditioner = other.Conditioner
+	}
+	if other.MaxAttempts > 0 {
+		p.MaxAttempts = other.MaxAttempts
+	}
+	return p
+}
@@ -0,0 +1,86 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamedPolicies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1), retryOn5XX, WithNamedPolicies(map[string]Policy{
+		"critical":    {BackOff: backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 4)},
+		"best-effort": {Conditioner: func(resp *http.Response) (bool, error) { return PermanentError("not worth it") }},
+	}))
+
+	send := func(tag string) (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		if tag != "" {
+			req = req.WithContext(TagRequest(req.Context(), tag))
+		}
+		return client.Do(req)
+	}
+
+	resp, err := send("")
+	assert.EqualError(t, err, "bad status code 503")
+	assert.Equal(t, 2, Attempts(resp))
+
+	resp, err = send("critical")
+	assert.EqualError(t, err, "bad status code 503")
+	assert.Equal(t, 5, Attempts(resp))
+
+	resp, err = send("best-effort")
+	assert.EqualError(t, err, "not worth it")
+	assert.Equal(t, 1, Attempts(resp))
+
+	resp, err = send("unknown")
+	assert.EqualError(t, err, "bad status code 503")
+	assert.Equal(t, 2, Attempts(resp))
+}
+
+func TestPolicyFor(t *testing.T) {
+	payments := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer payments.Close()
+	search := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer search.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithMaxAttempts(2),
+		WithPolicyFor(MatchPathPrefix("/health"), Policy{MaxAttempts: 1}),
+		WithPolicyFor(MatchHost(mustParseURL(payments.URL).Host), Policy{MaxAttempts: 5}),
+		WithNamedPolicies(map[string]Policy{"once": {MaxAttempts: 1}}))
+
+	send := func(url string, tag string) int {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		if tag != "" {
+			req = req.WithContext(TagRequest(req.Context(), tag))
+		}
+		resp, err := client.Do(req)
+		assert.Error(t, err)
+		return Attempts(resp)
+	}
+
+	assert.Equal(t, 5, send(payments.URL, ""))
+	assert.Equal(t, 1, send(payments.URL+"/health", ""), "the first match wins")
+	assert.Equal(t, 1, send(payments.URL, "once"), "tags take precedence")
+	assert.Equal(t, 2, send(search.URL, ""), "the client limit is the fallback")
+}
+
+func TestMatchMethod(t *testing.T) {
+	match := MatchMethod(http.MethodGet, http.MethodHead)
+
+	get, _ := http.NewRequest("", "http://localhost", nil)
+	post, _ := http.NewRequest(http.MethodPost, "http://localhost", nil)
+	assert.True(t, match(get))
+	assert.False(t, match(post))
+}
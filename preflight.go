@@ -0,0 +1,48 @@
+package httpeeve
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cenkalti/backoff"
+)
+
+// CanRetry reports, before req is sent, whether it can be safely retried by the client, along with a
+// human-readable reason. It looks at whether the body can be replayed, the method and the backoff of the
+// policy req selects. A body can be replayed if the request has GetBody, as set by "net/http".NewRequest for
+// in-memory bodies, if the body is seekable, or if the client buffers it anyway to transform or fingerprint
+// it. Any other body is a bare reader, which Do sends only once unless it is small enough for
+// WithBodyBuffering.
+func (c *BackoffClient) CanRetry(req *http.Request) (bool, string) {
+	if !c.retriesMethod(req.Method) {
+		if c.idempotentOnly && !isIdempotent(req.Method) {
+			return false, fmt.Sprintf("%s is not idempotent, see WithIdempotentOnly", req.Method)
+		}
+		return false, fmt.Sprintf("%s is configured as a non-retriable method", methodOrGet(req.Method))
+	}
+
+	if _, ok := c.policyFor(req).BackOff.(*backoff.StopBackOff); ok {
+		return false, "the backoff never allows a retry"
+	}
+
+	method := methodOrGet(req.Method)
+	_, seekable := req.Body.(io.Seeker)
+	switch transformed := c.bodyTransform != nil; {
+	case req.Body == nil || req.Body == http.NoBody:
+	case seekable && c.maxHedges == 0 && !transformed:
+		return true, fmt.Sprintf("%s request with a seekable body", method)
+	case req.GetBody != nil && !transformed:
+		return true, fmt.Sprintf("%s request with a body that can be recreated with GetBody", method)
+	case transformed || c.checkFingerprint:
+		return true, fmt.Sprintf("%s request with a body that is buffered to be transformed or fingerprinted", method)
+	case c.maxBufferedBody > 0:
+		return true, fmt.Sprintf("%s request with a body that is buffered if it is at most %d bytes",
+			method, c.maxBufferedBody)
+	default:
+		return false, fmt.Sprintf("%s request with a body that is a bare reader and cannot be replayed without buffering it",
+			method)
+	}
+
+	return true, fmt.Sprintf("%s request without a body", method)
+}
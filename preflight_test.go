@@ -0,0 +1,66 @@
+package httpeeve
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanRetry(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotentOnly(false))
+
+	get, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	ok, reason := client.CanRetry(get)
+	assert.True(t, ok)
+	assert.Equal(t, "GET request without a body", reason)
+
+	post, _ := http.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader([]byte("{}")))
+	ok, reason = client.CanRetry(post)
+	assert.True(t, ok)
+	assert.Equal(t, "POST request with a body that can be recreated with GetBody", reason)
+
+	bare, _ := http.NewRequest(http.MethodPost, "http://example.com", io.MultiReader(bytes.NewReader([]byte("{}"))))
+	ok, reason = client.CanRetry(bare)
+	assert.False(t, ok)
+	assert.Equal(t, "POST request with a body that is a bare reader and cannot be replayed without buffering it", reason)
+}
+
+func TestCanRetryWithFingerprintCheck(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotentOnly(false), WithFingerprintCheck())
+
+	bare, _ := http.NewRequest(http.MethodPost, "http://example.com", io.MultiReader(bytes.NewReader([]byte("{}"))))
+	ok, reason := client.CanRetry(bare)
+	assert.True(t, ok, "the body is buffered to be fingerprinted")
+	assert.Equal(t, "POST request with a body that is buffered to be transformed or fingerprinted", reason)
+}
+
+func TestCanRetryWithStopBackOff(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.StopBackOff{}, retryOn5XX)
+
+	get, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	ok, reason := client.CanRetry(get)
+	assert.False(t, ok)
+	assert.Equal(t, "the backoff never allows a retry", reason)
+}
+
+func TestCanRetryWithNonRetriableMethod(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, NonRetriableMethods("patch"), WithIdempotentOnly(false))
+
+	patch, _ := http.NewRequest(http.MethodPatch, "http://example.com", nil)
+	ok, reason := client.CanRetry(patch)
+	assert.False(t, ok)
+	assert.Equal(t, "PATCH is configured as a non-retriable method", reason)
+}
+
+func TestCanRetryNonIdempotentMethodByDefault(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	post, _ := http.NewRequest(http.MethodPost, "http://example.com", bytes.NewReader([]byte("{}")))
+	ok, reason := client.CanRetry(post)
+	assert.False(t, ok)
+	assert.Equal(t, "POST is not idempotent, see WithIdempotentOnly", reason)
+}
@@ -0,0 +1,227 @@
+package httpeeve
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// Recording captures how a request sent through a client went, attempt by attempt, so that other retry
+// policies can be evaluated against it with Replay.
+type Recording struct {
+	Method   string            `json:"method"`
+	URL      string            `json:"url"`
+	Start    time.Time         `json:"start"`
+	Attempts []RecordedAttempt `json:"attempts"`
+}
+
+// RecordedAttempt is the outcome of a single attempt of a Recording.
+type RecordedAttempt struct {
+	// StatusCode is the status code of the response, or 0 if the attempt got none.
+	StatusCode int `json:"status_code,omitempty"`
+	// Error is the message of the error of an attempt without a response.
+	Error string `json:"error,omitempty"`
+	// Retriable tells whether the error of an attempt without a response was considered worth retrying.
+	Retriable bool `json:"retriable,omitempty"`
+	// Duration is how long the attempt took, and Wait how long the client waited before it.
+	Duration time.Duration `json:"duration"`
+	Wait     time.Duration `json:"wait,omitempty"`
+}
+
+// Recorder receives a Recording of every request sent through a client, see WithRecorder. Implementations must
+// be safe for concurrent use.
+type Recorder interface {
+	Record(recording Recording)
+}
+
+// WithRecorder passes a Recording of every request to recorder once the client is done with it, for instance a
+// JSONLinesRecorder, to evaluate other retry policies offline with Replay.
+func WithRecorder(recorder Recorder) Option {
+	return func(c *BackoffClient) {
+		c.recorder = recorder
+	}
+}
+
+// JSONLinesRecorder is a Recorder writing every Recording as a line of JSON, to be read with ReadRecordings.
+type JSONLinesRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONLinesRecorder creates a JSONLinesRecorder writing to w.
+func NewJSONLinesRecorder(w io.Writer) *JSONLinesRecorder {
+	return &JSONLinesRecorder{enc: json.NewEncoder(w)}
+}
+
+// Record writes recording, unless writing failed before.
+func (r *JSONLinesRecorder) Record(recording Recording) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.enc.Encode(recording)
+	}
+}
+
+// Err returns the first error writing a Recording, if any.
+func (r *JSONLinesRecorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// ReadRecordings reads the lines of JSON written by a JSONLinesRecorder.
+func ReadRecordings(r io.Reader) ([]Recording, error) {
+	var recordings []Recording
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var recording Recording
+		if err := json.Unmarshal(scanner.Bytes(), &recording); err != nil {
+			return recordings, err
+		}
+		recordings = append(recordings, recording)
+	}
+	return recordings, scanner.Err()
+}
+
+// recording returns the Recording of the call, which ended in err.
+func (c *call) recording(err error) Recording {
+	recording := Recording{Method: methodOrGet(c.req.Method), URL: c.req.URL.String()}
+	for i, entry := range c.log {
+		if i == 0 {
+			recording.Start = entry.Start
+		}
+		attempt := RecordedAttempt{StatusCode: entry.StatusCode, Duration: entry.Duration, Wait: entry.Wait}
+		if entry.StatusCode == 0 && entry.Err != nil {
+			attempt.Error = entry.Err.Error()
+			// every attempt but the last was retried
+			attempt.Retriable = i < len(c.log)-1 || err != nil && !c.permanent
+		}
+		recording.Attempts = append(recording.Attempts, attempt)
+	}
+	return recording
+}
+
+// ReplayReport sums up what a retry policy would have done with a set of recordings, see Replay.
+type ReplayReport struct {
+	// Requests is the number of recordings replayed.
+	Requests int
+	// Attempts and Retries are the attempts the policy would have made, and how many of them were retries.
+	Attempts int
+	Retries  int
+	// Succeeded and Failed count the requests that would have ended in an accepted response or in an error.
+	Succeeded int
+	Failed    int
+	// Extrapolated counts the requests for which the policy would have made more attempts than were recorded.
+	// Their last recorded attempt is assumed to have gone the same way every time.
+	Extrapolated int
+	// Latency is the time the policy would have spent on all requests, attempts and waits included.
+	Latency time.Duration
+
+	// RecordedAttempts and RecordedLatency are the same for the recordings.
+	RecordedAttempts int
+	RecordedLatency  time.Duration
+}
+
+// AddedLatency is the time the policy would have spent on the requests on top of the recorded time, which is
+// negative if it would have given up sooner.
+func (r ReplayReport) AddedLatency() time.Duration {
+	return r.Latency - r.RecordedLatency
+}
+
+// Replay feeds the outcomes of recordings through policy and reports what it would have done: how many retries
+// it would have issued, how many requests would have succeeded and how long they would have taken. Responses
+// are judged by the Conditioner of the policy, with only their status codes to go by, while errors without a
+// response are retried if they were when recorded. A nil Conditioner accepts 2XXs and retries 5XXs, a nil
+// BackOff is the exponential backoff of NewClient. No request is retried more than 999 times.
+func Replay(recordings []Recording, policy Policy) ReplayReport {
+	conditioner := policy.Conditioner
+	if conditioner == nil {
+		conditioner = retryOn5XX
+	}
+	b := cloneBackOff(policy.BackOff)
+	if b == nil {
+		b = backoff.NewExponentialBackOff()
+	}
+	// time passes as the recordings say, so that limits on the elapsed time apply
+	clock := &replayClock{}
+	if exponential, ok := b.(*backoff.ExponentialBackOff); ok {
+		exponential.Clock = clock
+	}
+	if policy.MaxAttempts > 0 {
+		b = &limitBackOff{BackOff: b, maxRetries: policy.MaxAttempts - 1, now: clock.Now}
+	}
+
+	var report ReplayReport
+	for _, recording := range recordings {
+		if len(recording.Attempts) == 0 {
+			continue
+		}
+		report.Requests++
+		for _, attempt := range recording.Attempts {
+			report.RecordedAttempts++
+			report.RecordedLatency += attempt.Wait + attempt.Duration
+		}
+
+		req := &http.Request{Method: recording.Method, Header: http.Header{}}
+		req.URL, _ = url.Parse(recording.URL)
+
+		b.Reset()
+		for i := 0; ; i++ {
+			attempt := recording.Attempts[len(recording.Attempts)-1]
+			if i < len(recording.Attempts) {
+				attempt = recording.Attempts[i]
+			} else if i == len(recording.Attempts) {
+				report.Extrapolated++
+			}
+			report.Attempts++
+			report.Latency += attempt.Duration
+			clock.now = clock.now.Add(attempt.Duration)
+
+			retry, accepted := attempt.Retriable, false
+			if attempt.StatusCode != 0 {
+				advice := conditioner.advise(&http.Response{StatusCode: attempt.StatusCode, Header: http.Header{}, Body: http.NoBody, Request: req})
+				retry, accepted = advice.Retry && !advice.Permanent, !advice.Retry && !advice.Permanent
+			}
+			if accepted {
+				report.Succeeded++
+				break
+			}
+
+			next := backoff.Stop
+			if retry {
+				next = b.NextBackOff()
+			}
+			if next == backoff.Stop || i+1 >= maxReplayedAttempts {
+				report.Failed++
+				break
+			}
+			report.Retries++
+			report.Latency += next
+			clock.now = clock.now.Add(next)
+		}
+	}
+	return report
+}
+
+// maxReplayedAttempts keeps Replay from retrying forever with a policy that sets no limit.
+const maxReplayedAttempts = 1000
+
+// replayClock is the time of a replay, which only passes as the recordings say.
+type replayClock struct {
+	now time.Time
+}
+
+func (c *replayClock) Now() time.Time {
+	return c.now
+}
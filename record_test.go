@@ -0,0 +1,112 @@
+package httpeeve
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	recorder := NewJSONLinesRecorder(&buf)
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Millisecond), retryOn5XX, WithRecorder(recorder))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.NoError(t, recorder.Err())
+
+	recordings, err := ReadRecordings(&buf)
+	assert.NoError(t, err)
+	if assert.Len(t, recordings, 1) {
+		recording := recordings[0]
+		assert.Equal(t, http.MethodGet, recording.Method)
+		assert.Equal(t, server.URL+"/items", recording.URL)
+		assert.False(t, recording.Start.IsZero())
+		var statuses []int
+		for i, attempt := range recording.Attempts {
+			statuses = append(statuses, attempt.StatusCode)
+			assert.True(t, attempt.Duration > 0)
+			if i > 0 {
+				assert.Equal(t, time.Millisecond, attempt.Wait)
+			}
+		}
+		assert.Equal(t, []int{503, 503, 200}, statuses)
+	}
+}
+
+func TestRecorderRecordsErrors(t *testing.T) {
+	var buf bytes.Buffer
+	client := NewBackoffClient(http.Client{}, &backoff.StopBackOff{}, retryOn5XX, WithRecorder(NewJSONLinesRecorder(&buf)))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:1", nil)
+	_, err := client.Do(req)
+	assert.Error(t, err)
+
+	recordings, err := ReadRecordings(&buf)
+	assert.NoError(t, err)
+	if assert.Len(t, recordings, 1) && assert.Len(t, recordings[0].Attempts, 1) {
+		attempt := recordings[0].Attempts[0]
+		assert.Equal(t, 0, attempt.StatusCode)
+		assert.Contains(t, attempt.Error, "connection refused")
+		assert.True(t, attempt.Retriable, "refused connections are retriable, the backoff just did not allow it")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	recordings, err := ReadRecordings(strings.NewReader(`
+{"method":"GET","url":"http://example.com/a","attempts":[{"status_code":200,"duration":100000000}]}
+{"method":"GET","url":"http://example.com/b","attempts":[{"status_code":503,"duration":100000000},{"status_code":200,"duration":100000000,"wait":1000000000}]}
+{"method":"GET","url":"http://example.com/c","attempts":[{"error":"connection reset","retriable":true,"duration":100000000}]}
+{"method":"GET","url":"http://example.com/d","attempts":[{"status_code":404,"duration":100000000}]}
+`))
+	assert.NoError(t, err)
+
+	report := Replay(recordings, Policy{BackOff: backoff.NewConstantBackOff(time.Second), MaxAttempts: 3})
+	assert.Equal(t, ReplayReport{
+		Requests:         4,
+		Attempts:         1 + 2 + 3 + 1,
+		Retries:          1 + 2,
+		Succeeded:        2,
+		Failed:           2,
+		Extrapolated:     1,
+		Latency:          7*100*time.Millisecond + 3*time.Second,
+		RecordedAttempts: 5,
+		RecordedLatency:  5*100*time.Millisecond + time.Second,
+	}, report)
+	assert.Equal(t, 2*time.Second+200*time.Millisecond, report.AddedLatency())
+
+	report = Replay(recordings, Policy{BackOff: &backoff.StopBackOff{}, Conditioner: RetryOnStatus(http.StatusServiceUnavailable)})
+	assert.Equal(t, 4, report.Attempts)
+	assert.Equal(t, 0, report.Retries)
+	assert.Equal(t, 1, report.Succeeded)
+}
+
+func TestReplayStopsWithoutLimits(t *testing.T) {
+	recordings := []Recording{{Attempts: []RecordedAttempt{{StatusCode: 503, Duration: time.Second}}}}
+
+	exponential := backoff.NewExponentialBackOff()
+	exponential.MaxElapsedTime = time.Minute
+	report := Replay(recordings, Policy{BackOff: exponential})
+	assert.Equal(t, 1, report.Failed)
+	assert.True(t, report.Latency <= time.Minute+exponential.MaxInterval, "the elapsed time passes with the replay")
+
+	report = Replay(recordings, Policy{BackOff: &backoff.ZeroBackOff{}})
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, maxReplayedAttempts, report.Attempts)
+}
@@ -0,0 +1,105 @@
+package httpeeve
+
+import (
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+)
+
+// WithHTTPClient makes the client send its requests with httpClient itself, rather than with the copy of the
+// "net/http".Client it was created with, so that changes to it, such as a new Jar or CheckRedirect, take effect.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *BackoffClient) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetriableRedirects keeps the client from following redirects with one of statuses, and retries them
+// instead, for instance a 307 sent by a load balancer while the upstream restarts. The Retry-After header of
+// such a redirect is honored with WithRetryAfter.
+func WithRetriableRedirects(statuses ...int) Option {
+	return withRedirects(true, statuses)
+}
+
+// WithPermanentRedirects keeps the client from following redirects with one of statuses, and fails requests
+// that get them with an unretriable error, for instance a 302 to a login page after a session expired.
+func WithPermanentRedirects(statuses ...int) Option {
+	return withRedirects(false, statuses)
+}
+
+func withRedirects(retriable bool, statuses []int) Option {
+	return func(c *BackoffClient) {
+		if c.redirects == nil {
+			c.redirects = map[int]bool{}
+		}
+		for _, status := range statuses {
+			c.redirects[status] = retriable
+		}
+	}
+}
+
+// WithCookiesAcrossAttempts gives every request a cookie jar of its own, if the "net/http".Client has none, so
+// that cookies set in a response to one attempt, for instance by an authenticating proxy, are sent along with
+// the following attempts and redirects of the same request, but not with other requests.
+func WithCookiesAcrossAttempts() Option {
+	return func(c *BackoffClient) {
+		c.cookiesAcrossAttempts = true
+	}
+}
+
+var errTooManyRedirects = errors.New("stopped after 10 redirects")
+
+// redirectConditioner judges the redirects declared with WithRetriableRedirects and WithPermanentRedirects,
+// and leaves other responses to conditioner.
+func (c *BackoffClient) redirectConditioner(conditioner Conditioner) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		retriable, ok := c.redirects[resp.StatusCode]
+		switch {
+		case !ok:
+			return conditioner(resp)
+		case retriable:
+			return RetriableErrorf("redirect with status code %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+		default:
+			return PermanentErrorf("redirect with status code %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+		}
+	}
+}
+
+// withRedirectsAndCookies returns a copy of httpClient that does not follow the redirects for the Conditioner
+// and sends the cookies of the call, or httpClient itself if neither is configured.
+func (c *call) withRedirectsAndCookies(httpClient *http.Client) *http.Client {
+	if len(c.client.redirects) == 0 && c.jar == nil {
+		return httpClient
+	}
+
+	client := *httpClient
+	if c.jar != nil {
+		client.Jar = c.jar
+	}
+	if redirects := c.client.redirects; len(redirects) > 0 {
+		checkRedirect := httpClient.CheckRedirect
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if _, ok := redirects[req.Response.StatusCode]; ok {
+				return http.ErrUseLastResponse
+			}
+			if checkRedirect != nil {
+				return checkRedirect(req, via)
+			}
+			if len(via) >= 10 {
+				// the limit of the default policy of "net/http"
+				return errTooManyRedirects
+			}
+			return nil
+		}
+	}
+	return &client
+}
+
+// newCookieJar returns the cookie jar of a call, or nil if it uses the one of the "net/http".Client.
+func (c *BackoffClient) newCookieJar() http.CookieJar {
+	if !c.cookiesAcrossAttempts || c.httpClient.Jar != nil {
+		return nil
+	}
+	jar, _ := cookiejar.New(nil) // never fails without options
+	return jar
+}
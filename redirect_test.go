@@ -0,0 +1,114 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetriableRedirects(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/flaky":
+			requestCount++
+			if requestCount < 3 {
+				http.Redirect(w, req, "/maintenance", http.StatusTemporaryRedirect)
+			}
+		case "/moved":
+			http.Redirect(w, req, "/flaky", http.StatusMovedPermanently)
+		default:
+			t.Errorf("unexpected request to %s", req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithRetriableRedirects(http.StatusTemporaryRedirect))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/moved", nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, Attempts(resp), "other redirects are followed")
+}
+
+func TestWithPermanentRedirects(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		http.Redirect(w, req, "/login", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, RetryOnStatusRange(500, 599), WithPermanentRedirects(http.StatusFound))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/account", nil)
+	resp, err := client.Do(req)
+	assert.EqualError(t, err, `redirect with status code 302 to "/login"`)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, 1, requestCount)
+}
+
+func TestRedirectsKeepCheckRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, "/elsewhere", http.StatusMovedPermanently)
+	}))
+	defer server.Close()
+
+	var checked int
+	httpClient := http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		checked++
+		return http.ErrUseLastResponse
+	}}
+	client := NewBackoffClient(httpClient, &backoff.ZeroBackOff{}, RetryOnStatusRange(500, 599), WithRetriableRedirects(http.StatusTemporaryRedirect))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.EqualError(t, err, "bad status code 301")
+	assert.Equal(t, http.StatusMovedPermanently, resp.StatusCode)
+	assert.Equal(t, 1, checked)
+}
+
+func TestWithCookiesAcrossAttempts(t *testing.T) {
+	var cookies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		cookie, err := req.Cookie("session")
+		if err != nil {
+			cookies = append(cookies, "")
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t"})
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		cookies = append(cookies, cookie.Value)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithCookiesAcrossAttempts())
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, Attempts(resp))
+	}
+	assert.Equal(t, []string{"", "s3cr3t", "", "s3cr3t"}, cookies, "requests do not share cookies")
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{}
+	client := NewClient(http.Client{}, RetryOnStatusRange(500, 599), WithHTTPClient(httpClient), WithMaxRetries(0))
+	httpClient.Timeout = time.Millisecond
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.Error(t, err, "changes to the client take effect")
+}
@@ -0,0 +1,134 @@
+package httpeeve
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+var errBodyNotReplayable = errors.New("request body cannot be replayed for a retry, use a request with GetBody or a seekable body, or WithBodyBuffering")
+
+// WithBodyBuffering makes the client buffer request bodies that it cannot replay otherwise, such as a bare
+// "io".Reader, so that they are sent again when retrying. Bodies with GetBody or seekable ones are replayed
+// without a copy as before. At most maxBytes are buffered per request: a larger body is streamed and sent only
+// once, as are all bare bodies without this option.
+func WithBodyBuffering(maxBytes int64) Option {
+	return func(c *BackoffClient) {
+		c.maxBufferedBody = maxBytes
+	}
+}
+
+// prepareBody decides how the request body is sent again on every attempt, in order of preference:
+//
+//   - a seekable body is seeked back to where it stood when Do was called, unless attempts may run concurrently as hedges or the
+//     body is transformed per attempt
+//   - a body with GetBody is recreated with it, unless the body is transformed per attempt
+//   - a body that is transformed per attempt, or fingerprinted, is buffered, as it has to be read again
+//   - any other body is buffered if it fits WithBodyBuffering
+//   - any other body is sent once, and retrying the request results in errBodyNotReplayable
+//
+// It returns a function to release the body once the call is done.
+func (c *call) prepareBody() (func(), error) {
+	release := func() {}
+	req := c.req
+	if req.Body == nil || req.Body == http.NoBody {
+		return release, nil
+	}
+
+	seeker, seekable := req.Body.(io.ReadSeeker)
+	switch transformed := c.client.bodyTransform != nil; {
+	case seekable && c.client.maxHedges == 0 && !transformed:
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		c.getBody = seekableBody(seeker, offset)
+		release = func() { req.Body.Close() }
+
+	case req.GetBody != nil && !transformed:
+		c.getBody = recreatedBody(req)
+
+	case transformed || c.client.checkFingerprint:
+		bodyBytes, err := readBody(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		c.body = bodyBytes
+		c.getBody = func() io.ReadCloser {
+			return ioutil.NopCloser(bytes.NewReader(bodyBytes))
+		}
+
+	case c.client.maxBufferedBody > 0:
+		head, err := ioutil.ReadAll(io.LimitReader(req.Body, c.client.maxBufferedBody+1))
+		if err != nil {
+			req.Body.Close()
+			return nil, err
+		}
+		if int64(len(head)) <= c.client.maxBufferedBody {
+			req.Body.Close()
+			c.body = head
+			c.getBody = func() io.ReadCloser {
+				return ioutil.NopCloser(bytes.NewReader(head))
+			}
+			break
+		}
+		streamed := struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(head), req.Body), req.Body}
+		c.getBody = func() io.ReadCloser { return streamed }
+		c.unreplayable = true
+
+	default:
+		c.getBody = func() io.ReadCloser { return req.Body }
+		c.unreplayable = true
+	}
+
+	return release, nil
+}
+
+// seekableBody replays a request body by seeking back to offset, where it started, instead of buffering it,
+// which spares a copy of large uploads such as files. The body is handed out as it is the first time, and
+// without its Close, so that it survives the attempts that the transport closes.
+func seekableBody(body io.ReadSeeker, offset int64) func() io.ReadCloser {
+	first := true
+	return func() io.ReadCloser {
+		if first {
+			first = false
+			return ioutil.NopCloser(body)
+		}
+
+		if _, err := body.Seek(offset, io.SeekStart); err != nil {
+			return ioutil.NopCloser(errReader{err})
+		}
+		return ioutil.NopCloser(body)
+	}
+}
+
+// recreatedBody hands out the body of req the first time and recreates it with GetBody afterwards.
+func recreatedBody(req *http.Request) func() io.ReadCloser {
+	first := true
+	return func() io.ReadCloser {
+		if first {
+			first = false
+			return req.Body
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			return ioutil.NopCloser(errReader{err})
+		}
+		return body
+	}
+}
+
+// errReader fails every read with err.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,153 @@
+package httpeeve
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeekableBodyIsResentFromStart(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	file, err := ioutil.TempFile("", "httpeeve")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString("upload")
+	assert.NoError(t, err)
+	_, err = file.Seek(0, 0)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, file)
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotentOnly(false))
+
+	resp, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"upload", "upload", "upload"}, bodies)
+	assert.Error(t, file.Close(), "the body is closed once the call is done")
+}
+
+func TestPartlyReadSeekableBodyIsResentFromItsOffset(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	file, err := ioutil.TempFile("", "httpeeve")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString("header:upload")
+	assert.NoError(t, err)
+	_, err = file.Seek(int64(len("header:")), io.SeekStart)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, file)
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotentOnly(false))
+
+	resp, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"upload", "upload"}, bodies)
+}
+
+func TestBodyIsRecreatedWithGetBody(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, bytes.NewBufferString(`{"id":1}`))
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotentOnly(false))
+
+	_, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"id":1}`, `{"id":1}`}, bodies)
+}
+
+func TestBareBodyIsNotRetried(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, io.MultiReader(strings.NewReader(`{"id":1}`)))
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotentOnly(false))
+
+	resp, err := client.Do(req)
+
+	assert.Equal(t, errBodyNotReplayable, err)
+	assert.Equal(t, []string{`{"id":1}`}, bodies)
+	assert.Equal(t, 1, Attempts(resp))
+}
+
+func TestBareBodyIsBuffered(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, io.MultiReader(strings.NewReader(`{"id":1}`)))
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotentOnly(false), WithBodyBuffering(8))
+
+	resp, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"id":1}`, `{"id":1}`, `{"id":1}`}, bodies)
+	assert.Equal(t, 3, Attempts(resp))
+}
+
+func TestBodyOverBufferLimitIsSentOnce(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, io.MultiReader(strings.NewReader(`{"id":10}`)))
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithIdempotentOnly(false), WithBodyBuffering(8))
+
+	resp, err := client.Do(req)
+
+	assert.Equal(t, errBodyNotReplayable, err)
+	assert.Equal(t, []string{`{"id":10}`}, bodies, "the body is streamed in full")
+	assert.Equal(t, 1, Attempts(resp))
+}
@@ -0,0 +1,30 @@
+package httpeeve
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type contextKeyResult struct{}
+
+// Result summarizes how a request went, whatever its outcome, see RecordResult.
+type Result struct {
+	// Attempts is the number of attempts made.
+	Attempts int
+	// Elapsed is how long Do took, including all attempts and waits.
+	Elapsed time.Duration
+}
+
+// RecordResult returns a copy of ctx that makes Do fill in result for requests made with it, once they are
+// done. Unlike Attempts, this also works when Do fails without a response, which makes it possible to report
+// a single summary per request regardless of its outcome.
+func RecordResult(ctx context.Context, result *Result) context.Context {
+	return context.WithValue(ctx, contextKeyResult{}, result)
+}
+
+func recordResult(req *http.Request, attempts int, start time.Time) {
+	if result, ok := req.Context().Value(contextKeyResult{}).(*Result); ok {
+		result.Attempts, result.Elapsed = attempts, time.Since(start)
+	}
+}
@@ -0,0 +1,46 @@
+package httpeeve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordResultWithoutResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := ts.URL
+	ts.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(backoff.NewConstantBackOff(10*time.Millisecond), 2), retryOn5XX,
+		WithRetriableSyscallErrors(syscall.ECONNREFUSED))
+
+	var result Result
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	resp, err := client.Do(req.WithContext(RecordResult(context.Background(), &result)))
+
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	assert.Equal(t, 3, result.Attempts)
+	assert.True(t, result.Elapsed >= 20*time.Millisecond, result.Elapsed)
+}
+
+func TestRecordResultOnSuccess(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	var result Result
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	_, err := client.Do(req.WithContext(RecordResult(context.Background(), &result)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Attempts)
+	assert.True(t, result.Elapsed > 0)
+}
@@ -0,0 +1,97 @@
+package httpeeve
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// WithNow replaces the clock the client uses for date math, such as working out how long to wait for an
+// HTTP-date in a Retry-After header. It defaults to time.Now and is mostly useful to pin the time in tests.
+func WithNow(now func() time.Time) Option {
+	return func(c *BackoffClient) {
+		c.now = now
+	}
+}
+
+// HonorRetryAfter wraps conditioner so that, whenever it decides to retry a response carrying a Retry-After
+// header, the client waits for as long as the header asks instead of the interval of its backoff. Both the
+// delay-seconds and the HTTP-date form are understood; a header that cannot be parsed is ignored.
+func HonorRetryAfter(conditioner Conditioner) Conditioner {
+	return HonorRetryAfterUpTo(0, conditioner)
+}
+
+// HonorRetryAfterUpTo is like HonorRetryAfter, but never waits longer than maxWait, so that an absurd header
+// cannot stall the client. A maxWait of 0 means no limit.
+func HonorRetryAfterUpTo(maxWait time.Duration, conditioner Conditioner) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		shouldRetry, err := conditioner(resp)
+		if err == nil || !shouldRetry {
+			return shouldRetry, err
+		}
+
+		value := resp.Header.Get("Retry-After")
+		if value == "" {
+			return shouldRetry, err
+		}
+
+		return true, &delaySuggestion{err: err, adjust: func(next time.Duration, now time.Time) time.Duration {
+			wait, ok := parseRetryAfter(value, now)
+			if !ok {
+				return next
+			}
+			if maxWait > 0 && wait > maxWait {
+				return maxWait
+			}
+			return wait
+		}}
+	}
+}
+
+// WithRetryAfter makes the client honor Retry-After headers like HonorRetryAfterUpTo, whatever its Conditioner
+// or the Policy of a request: whenever a response such as a 429 or a 503 is retried, the client waits as long as
+// its header asks, up to maxWait, instead of the interval of the backoff. A maxWait of 0 means no limit.
+func WithRetryAfter(maxWait time.Duration) Option {
+	return func(c *BackoffClient) {
+		c.honorRetryAfter, c.maxRetryAfter = true, maxWait
+	}
+}
+
+// NewRetryAfterClient retries requests that result in 5XXs or 429 Too Many Requests, waiting as long as their
+// Retry-After header asks, up to maxWait, and following an exponential backoff otherwise. Like
+// NewDefaultBackoffClient5XX it accepts 2XXs and gives up on everything else.
+func NewRetryAfterClient(httpClient http.Client, maxWait time.Duration, opts ...Option) *BackoffClient {
+	return NewBackoffClient(httpClient, backoff.NewExponentialBackOff(), HonorRetryAfterUpTo(maxWait, retryOn429Or5XX), opts...)
+}
+
+// maxRetryAfterSeconds keeps huge delay-seconds from overflowing a time.Duration.
+const maxRetryAfterSeconds = int64(math.MaxInt64 / time.Second)
+
+// parseRetryAfter returns how long a Retry-After header value asks to wait, relative to now for HTTP-dates.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		if seconds > maxRetryAfterSeconds {
+			seconds = maxRetryAfterSeconds
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
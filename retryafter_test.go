@@ -0,0 +1,159 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+var pinnedNow = time.Date(2019, time.May, 2, 10, 0, 0, 0, time.UTC)
+
+func TestParseRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		wait  time.Duration
+		ok    bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"Thu, 02 May 2019 10:00:30 GMT", 30 * time.Second, true},
+		{"Thu, 02 May 2019 09:59:00 GMT", 0, true},
+		{"-5", 0, false},
+		{"soon", 0, false},
+	} {
+		wait, ok := parseRetryAfter(tc.value, pinnedNow)
+		assert.Equal(t, tc.ok, ok, tc.value)
+		assert.Equal(t, tc.wait, wait, tc.value)
+	}
+}
+
+func TestHonorRetryAfterUsesClientClock(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Millisecond), retryOn5XX, WithNow(func() time.Time {
+		return pinnedNow
+	}))
+
+	resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+	resp.Header.Set("Retry-After", pinnedNow.Add(90*time.Second).Format(http.TimeFormat))
+
+	_, err := HonorRetryAfter(retryOn5XX)(resp)
+	b := &suggestingBackOff{BackOff: client.backoffer, now: client.now}
+	assert.EqualError(t, b.suggest(err), "bad status code 503")
+	assert.Equal(t, 90*time.Second, b.NextBackOff())
+	assert.Equal(t, time.Millisecond, b.NextBackOff(), "the suggestion only applies to the next attempt")
+}
+
+func TestHonorRetryAfterIgnoresUnparseableHeaders(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": {"later"}}}
+
+	_, err := HonorRetryAfter(retryOn5XX)(resp)
+	b := &suggestingBackOff{BackOff: backoff.NewConstantBackOff(time.Millisecond), now: time.Now}
+	b.suggest(err)
+	assert.Equal(t, time.Millisecond, b.NextBackOff())
+}
+
+func TestHonorRetryAfterOverridesBackoff(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Hour), HonorRetryAfter(retryOn5XX))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp))
+}
+
+func TestHonorRetryAfterUpToClampsAbsurdValues(t *testing.T) {
+	for _, value := range []string{"86400", "99999999999999999", "Thu, 02 May 2119 10:00:00 GMT"} {
+		resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": {value}}}
+
+		_, err := HonorRetryAfterUpTo(time.Minute, retryOn5XX)(resp)
+		b := &suggestingBackOff{BackOff: backoff.NewConstantBackOff(time.Millisecond), now: func() time.Time { return pinnedNow }}
+		b.suggest(err)
+		assert.Equal(t, time.Minute, b.NextBackOff(), value)
+	}
+}
+
+func TestRetryAfterClient(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewRetryAfterClient(http.Client{}, time.Millisecond)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, Attempts(resp))
+	assert.Equal(t, []AttemptLogEntry{
+		{Attempt: 1, Trigger: "initial", StatusCode: 429},
+		{Attempt: 2, Trigger: "retry:429", Wait: time.Millisecond, StatusCode: 429},
+		{Attempt: 3, Trigger: "retry:429", Wait: time.Millisecond, StatusCode: 200},
+	}, withoutErrors(withoutTimings(AttemptLog(resp))))
+}
+
+func withoutTimings(log []AttemptLogEntry) []AttemptLogEntry {
+	for i := range log {
+		log[i].Start, log[i].Duration = time.Time{}, 0
+	}
+	return log
+}
+
+// withoutErrors drops the errors of log, which carry stack traces and cannot be compared.
+func withoutErrors(log []AttemptLogEntry) []AttemptLogEntry {
+	for i := range log {
+		log[i].Err = nil
+	}
+	return log
+}
+
+func TestWithRetryAfter(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		switch requestCount {
+		case 1:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Millisecond), retryOn429Or5XX,
+		WithRetryAfter(time.Minute), WithSleepFunc(func(d time.Duration) { delays = append(delays, d) }))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, Attempts(resp))
+	assert.Equal(t, []time.Duration{2 * time.Second, time.Minute, time.Millisecond}, delays)
+}
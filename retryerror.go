@@ -0,0 +1,61 @@
+package httpeeve
+
+import "net/http"
+
+// RetryError is returned by Do when the client gave up on a request because the backoff, or one of the limits of
+// the client, allowed no further retries. Its message is the one of the last error, and it unwraps to it.
+// Unretriable errors are returned as they are, except for a *PanicError, which comes in a RetryError with the
+// history of the request.
+type RetryError struct {
+	// Attempts is the number of attempts made.
+	Attempts int
+	// LastStatusCode is the status code of the last response, or 0 if the last attempt got no response.
+	LastStatusCode int
+	// Errors holds the errors of all attempts in order, as returned by the Conditioner or the transport.
+	Errors []error
+	// History is the log of all attempts, see AttemptHistory.
+	History []AttemptLogEntry
+	// Response is the last response, or nil if the last attempt got no response. Do returns it as well.
+	Response *http.Response
+	// Limit is ErrMaxAttemptsExceeded or ErrMaxElapsedTimeExceeded if the client ran into one of its own limits,
+	// and nil if it was the backoff that allowed no further retries.
+	Limit error
+}
+
+func (e *RetryError) Error() string {
+	return e.last().Error()
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *RetryError) Unwrap() error {
+	return e.last()
+}
+
+// Is tells whether target is the limit the client ran into.
+func (e *RetryError) Is(target error) bool {
+	return e.Limit != nil && target == e.Limit
+}
+
+func (e *RetryError) last() error {
+	return e.Errors[len(e.Errors)-1]
+}
+
+// retryError returns the error of a call that ran out of retries, after running into limit if it is not nil.
+func (c *call) retryError(limit error) error {
+	return &RetryError{
+		Attempts:       c.attempts,
+		LastStatusCode: statusCodeOf(c.resp),
+		Errors:         c.errors,
+		History:        c.log,
+		Response:       c.resp,
+		Limit:          limit,
+	}
+}
+
+// statusCodeOf returns the status code of resp, or 0 for a nil response.
+func statusCodeOf(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,58 @@
+package httpeeve
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryErrorWhenRetriesAreExhausted(t *testing.T) {
+	statuses := []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		w.WriteHeader(statuses[requestCount-1])
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), retryOn5XX)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+
+	var retryErr *RetryError
+	if assert.True(t, errors.As(err, &retryErr)) {
+		assert.Equal(t, 3, retryErr.Attempts)
+		assert.Equal(t, http.StatusGatewayTimeout, retryErr.LastStatusCode)
+		assert.Len(t, retryErr.Errors, 3)
+		assert.EqualError(t, retryErr.Errors[0], "bad status code 502")
+		assert.EqualError(t, retryErr.Errors[1], "bad status code 503")
+	}
+	assert.EqualError(t, err, "bad status code 504")
+}
+
+func TestPermanentErrorIsNotARetryError(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+
+	var retryErr *RetryError
+	assert.False(t, errors.As(err, &retryErr))
+	assert.EqualError(t, err, "bad status code 400")
+}
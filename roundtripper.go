@@ -0,0 +1,40 @@
+package httpeeve
+
+import (
+	"net/http"
+)
+
+// NewRoundTripper returns a "net/http".RoundTripper that sends requests with inner, retrying them like a client
+// created by NewClient with conditioner and opts. Set it as the Transport of a "net/http".Client to get retries
+// through plumbing that only accepts such clients or RoundTrippers. A nil inner stands for
+// "net/http".DefaultTransport. Redirects are left to the client using the RoundTripper.
+func NewRoundTripper(inner http.RoundTripper, conditioner Conditioner, opts ...Option) *BackoffClient {
+	httpClient := http.Client{
+		Transport: inner,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return NewClient(httpClient, conditioner, opts...)
+}
+
+// RoundTrip implements "net/http".RoundTripper, so that a BackoffClient can serve as the Transport of a
+// "net/http".Client. Unlike Do, it follows the contract of RoundTrip: once the client has given up on a
+// response, such as a 503 that is still failing, the response is returned without an error and it is up to
+// the caller to judge its status. Errors are only returned when there is no response. The request of the
+// caller is not modified; the body is replayed as described for Do.
+func (c *BackoffClient) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := c.Do(req.Clone(req.Context()))
+	if resp != nil {
+		return resp, nil
+	}
+	return nil, err
+}
+
+// NewStdCompatibleClient returns a "net/http".Client that retries like NewDefaultBackoffClient5XX, for
+// libraries that demand a *http.Client. Redirects, cookies and timeouts are left to the returned client and
+// can be configured on it as usual.
+func NewStdCompatibleClient() *http.Client {
+	return &http.Client{Transport: NewRoundTripper(nil, retryOn5XX)}
+}
@@ -0,0 +1,118 @@
+package httpeeve
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fetch stands in for a third-party library that only accepts a *http.Client.
+func fetch(client *http.Client, url string) (int, string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), err
+}
+
+func TestStdCompatibleClient(t *testing.T) {
+	var requestCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		switch {
+		case r.URL.Path == "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case requestCount < 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("hello"))
+		}
+	}))
+	defer ts.Close()
+
+	status, body, err := fetch(NewStdCompatibleClient(), ts.URL+"/old")
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "hello", body)
+	assert.Equal(t, 3, requestCount)
+}
+
+func TestStdCompatibleClientReturnsFailedResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	status, _, err := fetch(NewStdCompatibleClient(), ts.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestRoundTripperWrapsInnerTransport(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer primary.Close()
+
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Use-Primary", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer replica.Close()
+
+	var innerCalls int
+	inner := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		innerCalls++
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	primaryURL, _ := url.Parse(primary.URL)
+	client := &http.Client{Transport: NewRoundTripper(inner, RetryOnFailoverHint("X-Use-Primary", primaryURL, retryOn5XX),
+		WithBackoff(&backoff.ZeroBackOff{}))}
+
+	req, _ := http.NewRequest(http.MethodGet, replica.URL+"/path", nil)
+	resp, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, innerCalls)
+	assert.Equal(t, replica.URL+"/path", req.URL.String(), "the request of the caller is left alone")
+}
+
+func TestRoundTripperComposesWithOtherTransports(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if r.Header.Get("Authorization") != "Bearer token" || requestCount == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var traced []string
+	tracing := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		traced = append(traced, req.Header.Get("Authorization"))
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	retrying := NewRoundTripper(tracing, retryOn5XX, WithBackoff(&backoff.ZeroBackOff{}))
+	auth := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer token")
+		return retrying.RoundTrip(req)
+	})
+
+	status, _, err := fetch(&http.Client{Transport: auth}, server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"Bearer token", "Bearer token"}, traced, "every attempt goes through the inner transport")
+}
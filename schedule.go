@@ -0,0 +1,172 @@
+package httpeeve
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// Schedule declares a well-known retry strategy without tuning a backoff.BackOff by hand: build one with
+// Exponential, Constant, Linear or Fibonacci, optionally add jitter so that clients failing together do not
+// retry together, and pass it to WithSchedule. The zero Schedule retries right away.
+type Schedule struct {
+	kind   string
+	delay  func(retry int) time.Duration
+	base   time.Duration
+	max    time.Duration
+	jitter jitter
+}
+
+type jitter int
+
+func (j jitter) String() string {
+	switch j {
+	case fullJitter:
+		return "full"
+	case equalJitter:
+		return "equal"
+	case decorrelatedJitter:
+		return "decorrelated"
+	default:
+		return ""
+	}
+}
+
+const (
+	noJitter jitter = iota
+	fullJitter
+	equalJitter
+	decorrelatedJitter
+)
+
+// Exponential waits base before the first retry and twice as long before every further one, at most max. A max
+// of 0 or less does not limit the wait.
+func Exponential(base, max time.Duration) Schedule {
+	return Schedule{delay: func(retry int) time.Duration {
+		wait := base
+		for i := 0; i < retry && (max <= 0 || wait < max); i++ {
+			if wait > math.MaxInt64/2 {
+				return math.MaxInt64
+			}
+			wait *= 2
+		}
+		return wait
+	}, kind: "exponential", base: base, max: max}
+}
+
+// Constant waits wait before every retry.
+func Constant(wait time.Duration) Schedule {
+	return Schedule{kind: "constant", delay: func(int) time.Duration { return wait }, base: wait}
+}
+
+// Linear waits step before the first retry and step longer before every further one.
+func Linear(step time.Duration) Schedule {
+	return Schedule{delay: func(retry int) time.Duration {
+		if step > 0 && int64(retry+1) > math.MaxInt64/int64(step) {
+			return math.MaxInt64
+		}
+		return step * time.Duration(retry+1)
+	}, kind: "linear", base: step}
+}
+
+// Fibonacci waits unit before the first two retries and the sum of the two previous waits before every further
+// one: 1, 1, 2, 3, 5, 8... times unit.
+func Fibonacci(unit time.Duration) Schedule {
+	return Schedule{delay: func(retry int) time.Duration {
+		previous, wait := time.Duration(0), unit
+		for i := 0; i < retry; i++ {
+			if wait > math.MaxInt64-previous {
+				return math.MaxInt64
+			}
+			previous, wait = wait, previous+wait
+		}
+		return wait
+	}, kind: "fibonacci", base: unit}
+}
+
+// WithMax limits every wait of s to max. A max of 0 or less does not limit the wait.
+func (s Schedule) WithMax(max time.Duration) Schedule {
+	s.max = max
+	return s
+}
+
+// WithFullJitter waits a random time between 0 and the wait of s.
+func (s Schedule) WithFullJitter() Schedule {
+	s.jitter = fullJitter
+	return s
+}
+
+// WithEqualJitter waits half the wait of s plus a random time of up to the other half.
+func (s Schedule) WithEqualJitter() Schedule {
+	s.jitter = equalJitter
+	return s
+}
+
+// WithDecorrelatedJitter waits a random time between the base wait of s and three times the previous wait, at
+// most the max of s. The waits grow on their own, so only the base and the max of s matter.
+func (s Schedule) WithDecorrelatedJitter() Schedule {
+	s.jitter = decorrelatedJitter
+	return s
+}
+
+// BackOff returns a new backoff.BackOff following s, for the APIs that take one.
+func (s Schedule) BackOff() backoff.BackOff {
+	return &scheduleBackOff{schedule: s, random: rand.Float64}
+}
+
+// WithSchedule replaces the exponential backoff of NewClient with schedule, see WithBackoffFactory.
+func WithSchedule(schedule Schedule) Option {
+	return WithBackoffFactory(schedule.BackOff)
+}
+
+type scheduleBackOff struct {
+	schedule Schedule
+	random   func() float64
+
+	retry    int
+	previous time.Duration
+}
+
+func (b *scheduleBackOff) Reset() {
+	b.retry, b.previous = 0, 0
+}
+
+func (b *scheduleBackOff) NextBackOff() time.Duration {
+	s := b.schedule
+	var wait time.Duration
+	if s.delay != nil {
+		wait = b.capped(s.delay(b.retry))
+	}
+
+	switch s.jitter {
+	case fullJitter:
+		wait = time.Duration(b.random() * float64(wait))
+	case equalJitter:
+		wait = wait/2 + time.Duration(b.random()*float64(wait-wait/2))
+	case decorrelatedJitter:
+		previous := b.previous
+		if previous < s.base {
+			previous = s.base
+		}
+		spread := b.random() * (3*float64(previous) - float64(s.base))
+		if spread >= float64(math.MaxInt64-s.base) {
+			wait = math.MaxInt64
+		} else {
+			wait = s.base + time.Duration(spread)
+		}
+		wait = b.capped(wait)
+	}
+
+	b.retry++
+	b.previous = wait
+	return wait
+}
+
+func (b *scheduleBackOff) capped(wait time.Duration) time.Duration {
+	if max := b.schedule.max; max > 0 && wait > max {
+		return max
+	}
+	return wait
+}
@@ -0,0 +1,85 @@
+package httpeeve
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waits returns the first n waits of s, with random always returning random.
+func waits(s Schedule, random float64, n int) []time.Duration {
+	b := &scheduleBackOff{schedule: s, random: func() float64 { return random }}
+	var waits []time.Duration
+	for i := 0; i < n; i++ {
+		waits = append(waits, b.NextBackOff())
+	}
+	return waits
+}
+
+func TestSchedules(t *testing.T) {
+	ms := time.Millisecond
+	for name, tc := range map[string]struct {
+		schedule Schedule
+		random   float64
+		waits    []time.Duration
+	}{
+		"exponential":          {Exponential(100*ms, time.Second), 0, []time.Duration{100 * ms, 200 * ms, 400 * ms, 800 * ms, time.Second, time.Second}},
+		"uncapped exponential": {Exponential(ms, 0), 0, []time.Duration{ms, 2 * ms, 4 * ms, 8 * ms, 16 * ms, 32 * ms}},
+		"full jitter":          {Exponential(100*ms, time.Second).WithFullJitter(), 0.5, []time.Duration{50 * ms, 100 * ms, 200 * ms, 400 * ms, 500 * ms, 500 * ms}},
+		"equal jitter":         {Exponential(100*ms, time.Second).WithEqualJitter(), 0.5, []time.Duration{75 * ms, 150 * ms, 300 * ms, 600 * ms, 750 * ms, 750 * ms}},
+		"decorrelated jitter":  {Exponential(100*ms, time.Second).WithDecorrelatedJitter(), 1, []time.Duration{300 * ms, 900 * ms, time.Second, time.Second, time.Second, time.Second}},
+		"constant":             {Constant(time.Second), 0, []time.Duration{time.Second, time.Second, time.Second, time.Second, time.Second, time.Second}},
+		"linear":               {Linear(100 * ms).WithMax(450 * ms), 0, []time.Duration{100 * ms, 200 * ms, 300 * ms, 400 * ms, 450 * ms, 450 * ms}},
+		"fibonacci":            {Fibonacci(ms), 0, []time.Duration{ms, ms, 2 * ms, 3 * ms, 5 * ms, 8 * ms}},
+		"zero":                 {Schedule{}, 0, []time.Duration{0, 0, 0, 0, 0, 0}},
+	} {
+		assert.Equal(t, tc.waits, waits(tc.schedule, tc.random, len(tc.waits)), name)
+	}
+}
+
+func TestSchedulesDoNotOverflow(t *testing.T) {
+	for name, s := range map[string]Schedule{
+		"exponential":         Exponential(time.Hour, 0),
+		"linear":              Linear(math.MaxInt64 / 2),
+		"fibonacci":           Fibonacci(time.Hour),
+		"decorrelated jitter": Exponential(math.MaxInt64/2, 0).WithDecorrelatedJitter(),
+	} {
+		w := waits(s, 1, 100)
+		assert.Equal(t, time.Duration(math.MaxInt64), w[len(w)-1], name)
+		for _, wait := range w {
+			assert.True(t, wait > 0, name)
+		}
+	}
+}
+
+func TestScheduleResets(t *testing.T) {
+	b := Exponential(time.Millisecond, 0).BackOff()
+	b.NextBackOff()
+	b.NextBackOff()
+	b.Reset()
+	assert.Equal(t, time.Millisecond, b.NextBackOff())
+}
+
+func TestWithSchedule(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < 4 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := NewClient(http.Client{}, retryOn5XX, WithSchedule(Linear(time.Second)),
+		WithSleepFunc(func(d time.Duration) { delays = append(delays, d) }))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, delays)
+}
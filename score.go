@@ -0,0 +1,34 @@
+package httpeeve
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+// ScoreConditioner decides on responses by a heuristic score, for decisions beyond status codes. Responses
+// scoring at least acceptAbove are accepted and responses scoring below retryBelow are retried. Scores in
+// between are ambiguous: the first attempt is retried once, and an ambiguous retry results in an unretriable
+// error. score may read the body of the response, it is restored afterwards.
+func ScoreConditioner(score func(resp *http.Response) float64, acceptAbove, retryBelow float64) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		body, err := peekBody(resp)
+		if err != nil {
+			return RetriableErrorf("reading response body: %s", err)
+		}
+
+		value := score(resp)
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		switch {
+		case value >= acceptAbove:
+			return OK()
+		case value < retryBelow:
+			return RetriableErrorf("response scored %g, below %g", value, retryBelow)
+		case attemptOf(resp).number <= 1:
+			return RetriableErrorf("response scored %g, which is ambiguous", value)
+		default:
+			return PermanentErrorf("response scored %g, which is still ambiguous", value)
+		}
+	}
+}
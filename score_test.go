@@ -0,0 +1,57 @@
+package httpeeve
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+// bodyScore scores a response by the number in its body, reading the body to the end.
+func bodyScore(resp *http.Response) float64 {
+	body, _ := ioutil.ReadAll(resp.Body)
+	score, _ := strconv.ParseFloat(strings.TrimSpace(string(body)), 64)
+	return score
+}
+
+func TestScoreConditioner(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		scores   []string
+		attempts int
+		err      string
+	}{
+		{name: "accept", scores: []string{"0.9"}, attempts: 1},
+		{name: "retry until accepted", scores: []string{"0.1", "0.2", "0.95"}, attempts: 3},
+		{name: "ambiguous is retried once", scores: []string{"0.5", "0.8"}, attempts: 2},
+		{name: "still ambiguous is permanent", scores: []string{"0.5", "0.6", "0.9"}, attempts: 2, err: "response scored 0.6, which is still ambiguous"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var requestCount int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				requestCount++
+				w.Write([]byte(tc.scores[requestCount-1]))
+			}))
+			defer server.Close()
+
+			client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, ScoreConditioner(bodyScore, 0.8, 0.3))
+
+			req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+			resp, err := client.Do(req)
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+			assert.Equal(t, tc.attempts, Attempts(resp))
+
+			body, _ := ioutil.ReadAll(resp.Body)
+			assert.Equal(t, tc.scores[tc.attempts-1], string(body), "the body is restored after scoring")
+		})
+	}
+}
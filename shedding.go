@@ -0,0 +1,20 @@
+package httpeeve
+
+import "sync/atomic"
+
+// WithLoadShedding protects the client under overload: while more than threshold requests are in flight,
+// errors that would otherwise be retried are returned right away.
+func WithLoadShedding(threshold int) Option {
+	return func(c *BackoffClient) {
+		c.sheddingThreshold = threshold
+	}
+}
+
+// InFlight returns the number of requests the client is currently sending or retrying.
+func (c *BackoffClient) InFlight() int {
+	return int(atomic.LoadInt64(&c.inFlight))
+}
+
+func (c *BackoffClient) shouldShedLoad() bool {
+	return c.sheddingThreshold > 0 && c.InFlight() > c.sheddingThreshold
+}
@@ -0,0 +1,56 @@
+package httpeeve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadShedding(t *testing.T) {
+	held, release := make(chan struct{}), make(chan struct{})
+	var failures int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/hold" {
+			close(held)
+			<-release
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if atomic.AddInt64(&failures, 1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithLoadShedding(1))
+
+	holding := make(chan error)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/hold", nil)
+		_, err := client.Do(req)
+		holding <- err
+	}()
+	<-held
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.EqualError(t, err, "bad status code 500")
+	assert.Equal(t, 1, Attempts(resp), "the request is shed instead of retried")
+	assert.Equal(t, 1, client.InFlight())
+
+	close(release)
+	assert.NoError(t, <-holding)
+	assert.Equal(t, 0, client.InFlight())
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err = client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, Attempts(resp), "a lone request is retried")
+}
@@ -0,0 +1,78 @@
+package httpeeve
+
+import (
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// WithSleepFunc replaces how the client waits between attempts, which by default is a timer that is cut short
+// when the context of the request is done. The client calls sleep with every wait the backoff asks for, so tests
+// can record the exact sequence of delays and skip the waiting altogether. A request whose context is done while
+// sleep runs is not retried once sleep returns.
+func WithSleepFunc(sleep func(time.Duration)) Option {
+	return func(c *BackoffClient) {
+		c.sleep = sleep
+	}
+}
+
+// Clock is the time source of a client: Now tells the time, for instance to parse Retry-After dates or to enforce
+// WithMaxElapsedTime, and Sleep waits between attempts. httpeevetest.Clock is a fake one that advances instantly.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// WithClock makes the client tell the time and wait between attempts with clock, as WithNow and WithSleepFunc
+// would together.
+func WithClock(clock Clock) Option {
+	return func(c *BackoffClient) {
+		c.now = clock.Now
+		c.sleep = clock.Sleep
+	}
+}
+
+// retryNotify is backoff.RetryNotify, except that it waits with the sleep function of the client if there is one.
+func (c *BackoffClient) retryNotify(operation backoff.Operation, b backoff.BackOffContext, notify backoff.Notify) error {
+	var t *time.Timer
+
+	b.Reset()
+	for {
+		err := operation()
+		if err == nil {
+			return nil
+		}
+
+		if permanent, ok := err.(*backoff.PermanentError); ok {
+			return permanent.Err
+		}
+
+		next := b.NextBackOff()
+		if next == backoff.Stop {
+			return err
+		}
+
+		notify(err, next)
+
+		if c.sleep != nil {
+			c.sleep(next)
+			if b.Context().Err() != nil {
+				return err
+			}
+			continue
+		}
+
+		if t == nil {
+			t = time.NewTimer(next)
+			defer t.Stop()
+		} else {
+			t.Reset(next)
+		}
+
+		select {
+		case <-b.Context().Done():
+			return err
+		case <-t.C:
+		}
+	}
+}
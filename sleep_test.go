@@ -0,0 +1,55 @@
+package httpeeve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSleepFunc(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exponential := backoff.NewExponentialBackOff()
+	exponential.RandomizationFactor = 0
+
+	var delays []time.Duration
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(exponential, 3), retryOn5XX, WithSleepFunc(func(d time.Duration) {
+		delays = append(delays, d)
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	start := time.Now()
+	_, err := client.Do(req)
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < 100*time.Millisecond, "nothing sleeps for real")
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, 750 * time.Millisecond, 1125 * time.Millisecond}, delays)
+}
+
+func TestSleepFuncStopsWhenContextIsDone(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Hour), retryOn5XX, WithSleepFunc(func(time.Duration) {
+		cancel()
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req.WithContext(ctx))
+	assert.Error(t, err)
+	assert.Equal(t, 1, requestCount)
+}
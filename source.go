@@ -0,0 +1,74 @@
+package httpeeve
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cenkalti/backoff"
+)
+
+// SourceResolver is the failure source for errors of custom resolvers, such as DNS-over-HTTPS clients used in a dialer.
+const SourceResolver = "resolver"
+
+// SourceError tags an error with the component it originates from, so that it can be retried apart from
+// errors of the target server. It is created with TagFailure.
+type SourceError struct {
+	Source string
+	// StatusCode is the HTTP status reported by the source, if it speaks HTTP, or 0.
+	StatusCode int
+	Err        error
+}
+
+func (e *SourceError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s failed with status code %d: %s", e.Source, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("%s failed: %s", e.Source, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// TagFailure is the hook point for custom dialers and transports to tag the source of a failure. A dialer
+// resolving names over HTTPS would, for instance, return TagFailure(SourceResolver, resp.StatusCode, err).
+func TagFailure(source string, statusCode int, err error) error {
+	return &SourceError{Source: source, StatusCode: statusCode, Err: err}
+}
+
+// WithSourceRetries retries failures tagged with source up to maxRetries times per request, with a cap of
+// their own that is separate from errors of the target server. Failures with a 408, 429 or 5XX status code,
+// or without a status code, are retried; others are permanent.
+func WithSourceRetries(source string, maxRetries int) Option {
+	return func(c *BackoffClient) {
+		if c.sourceRetries == nil {
+			c.sourceRetries = map[string]int{}
+		}
+		c.sourceRetries[source] = maxRetries
+	}
+}
+
+// categorizeSourceError categorizes errors with a failure source the client has a cap for.
+func (c *call) categorizeSourceError(err error) (error, bool) {
+	var sourceErr *SourceError
+	if !errors.As(err, &sourceErr) {
+		return nil, false
+	}
+
+	maxRetries, ok := c.client.sourceRetries[sourceErr.Source]
+	if !ok {
+		return nil, false
+	}
+
+	if c.sourceFailures == nil {
+		c.sourceFailures = map[string]int{}
+	}
+	c.sourceFailures[sourceErr.Source]++
+
+	transient := sourceErr.StatusCode == 0 || isTransientStatus(sourceErr.StatusCode)
+	if !transient || c.sourceFailures[sourceErr.Source] > maxRetries {
+		return backoff.Permanent(err), true
+	}
+
+	return err, true
+}
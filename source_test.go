@@ -0,0 +1,57 @@
+package httpeeve
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func resolverFailingTransport(failures int, statusCode int) *http.Transport {
+	var dials int
+	var dialer net.Dialer
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dials++
+			if dials <= failures {
+				return nil, TagFailure(SourceResolver, statusCode, errors.New("doh resolver unavailable"))
+			}
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+func TestSourceRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{Transport: resolverFailingTransport(2, 503)}, &backoff.ZeroBackOff{}, retryOn5XX, WithSourceRetries(SourceResolver, 2))
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, Attempts(resp))
+
+	client = NewBackoffClient(http.Client{Transport: resolverFailingTransport(10, 503)}, &backoff.ZeroBackOff{}, retryOn5XX, WithSourceRetries(SourceResolver, 2))
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err = client.Do(req)
+
+	var sourceErr *SourceError
+	assert.True(t, errors.As(err, &sourceErr))
+	assert.Equal(t, SourceResolver, sourceErr.Source)
+	assert.Contains(t, err.Error(), "resolver failed with status code 503: doh resolver unavailable")
+}
+
+func TestSourceRetriesArePermanentForClientErrors(t *testing.T) {
+	client := NewBackoffClient(http.Client{Transport: resolverFailingTransport(1, 400)}, &backoff.ZeroBackOff{}, retryOn5XX, WithSourceRetries(SourceResolver, 5), WithEvents(10))
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+	_, err := client.Do(req)
+	assert.Error(t, err)
+	assert.Len(t, client.Events(), 2, "one attempt started, then exhausted")
+}
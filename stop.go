@@ -0,0 +1,84 @@
+package httpeeve
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// DeadlineError is returned by Do when the request failed and its context ran out of time meanwhile, so that
+// callers can tell running out of time apart from failures that retrying would not have fixed. It matches
+// context.DeadlineExceeded with errors.Is.
+type DeadlineError struct {
+	// Deadline is the deadline of the request context.
+	Deadline time.Time
+	// Remaining is the time that was left until the deadline when Do gave up. It is positive if Do gave up
+	// early because the next retry would have been due after the deadline.
+	Remaining time.Duration
+	// Attempts is the number of attempts made before the deadline.
+	Attempts int
+	// Err is the error of the last attempt.
+	Err error
+}
+
+func (e *DeadlineError) Error() string {
+	if e.Remaining > 0 {
+		return fmt.Sprintf("deadline too close for a retry after %d attempts, %s left: %v", e.Attempts, e.Remaining, e.Err)
+	}
+	return fmt.Sprintf("deadline exceeded by %s after %d attempts: %v", -e.Remaining, e.Attempts, e.Err)
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *DeadlineError) Unwrap() error {
+	return e.Err
+}
+
+// Is tells whether target is context.DeadlineExceeded.
+func (e *DeadlineError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// withContextError makes err tell when the request failed because its context is done, or was about to be, as
+// of now.
+func withContextError(req *http.Request, attempts int, err error, outOfTime bool, now func() time.Time) error {
+	ctx := req.Context()
+	if err == nil {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && (outOfTime || ctx.Err() == context.DeadlineExceeded) {
+		return &DeadlineError{Deadline: deadline, Remaining: deadline.Sub(now()), Attempts: attempts, Err: err}
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w after %d attempts: %v", ctx.Err(), attempts, err)
+	}
+
+	return err
+}
+
+// deadlineStopBackOff stops retrying when the next retry would be due after the deadline of ctx, and
+// remembers that it did.
+type deadlineStopBackOff struct {
+	backoff.BackOff
+	ctx     context.Context
+	now     func() time.Time
+	stopped bool
+}
+
+func (b *deadlineStopBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next == backoff.Stop {
+		return next
+	}
+
+	if deadline, ok := b.ctx.Deadline(); ok && deadline.Sub(b.now()) < next {
+		b.stopped = true
+		return backoff.Stop
+	}
+
+	return next
+}
@@ -0,0 +1,98 @@
+package httpeeve
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadlineErrorWhenContextExpiresMidRetry(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	client := NewBackoffClient(http.Client{}, backoff.WithMaxRetries(backoff.NewConstantBackOff(20*time.Millisecond), 10), retryOn5XX)
+
+	_, err := client.Do(req.WithContext(ctx))
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	var deadlineErr *DeadlineError
+	if assert.True(t, errors.As(err, &deadlineErr)) {
+		deadline, _ := ctx.Deadline()
+		assert.Equal(t, deadline, deadlineErr.Deadline)
+		assert.True(t, deadlineErr.Remaining < 20*time.Millisecond, "gives up once the next retry would be too late")
+		assert.True(t, deadlineErr.Attempts > 1)
+	}
+}
+
+func TestDeadlineErrorUsesClientClock(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(time.Minute), retryOn5XX,
+		WithNow(func() time.Time { return deadline.Add(-30 * time.Second) }))
+
+	_, err := client.Do(req.WithContext(ctx))
+
+	var deadlineErr *DeadlineError
+	if assert.True(t, errors.As(err, &deadlineErr), "the next retry is due after the deadline of the client clock") {
+		assert.Equal(t, 30*time.Second, deadlineErr.Remaining)
+		assert.Equal(t, 1, deadlineErr.Attempts)
+	}
+}
+
+func TestNoDeadlineErrorOnPermanentFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX)
+
+	_, err := client.Do(req.WithContext(ctx))
+
+	assert.EqualError(t, err, "bad status code 400")
+}
+
+func TestRetriesStopWhenContextIsCancelled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	client := NewBackoffClient(http.Client{}, backoff.NewConstantBackOff(200*time.Millisecond), retryOn5XX)
+
+	start := time.Now()
+	resp, err := client.Do(req.WithContext(ctx))
+
+	assert.True(t, time.Since(start) < 200*time.Millisecond, "stops within one backoff interval")
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.EqualError(t, err, "context canceled after 1 attempts: bad status code 503")
+	assert.Equal(t, 1, Attempts(resp))
+}
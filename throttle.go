@@ -0,0 +1,159 @@
+package httpeeve
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// WithAdaptiveThrottle paces the attempts to every host to stay within its rate limit, rather than amplifying
+// the load on a rate-limited API with retries once it pushes back. The limit of a host starts at maxRate
+// attempts per second. Every response with the status code 429 Too Many Requests halves it, down to minRate,
+// and every other response raises it by minRate, up to maxRate again. Responses to attempts that were sent
+// before the limit last went down do not lower it any further, so that a burst of 429s counts once. An attempt
+// beyond the limit waits for its turn, unless the context of its request is done first. See RateLimit.
+//
+// As the limit recovers by minRate, it needs to be positive: a minRate of 0 or less is taken as a hundredth of
+// maxRate, and neither goes below MinThrottleRate.
+func WithAdaptiveThrottle(minRate, maxRate float64) Option {
+	if minRate <= 0 {
+		minRate = maxRate / 100
+	}
+	if minRate < MinThrottleRate {
+		minRate = MinThrottleRate
+	}
+	if maxRate < minRate {
+		maxRate = minRate
+	}
+	return func(c *BackoffClient) {
+		c.throttle = &adaptiveThrottle{
+			minRate: minRate,
+			maxRate: maxRate,
+			now:     func() time.Time { return c.now() },
+			hosts:   map[string]*hostThrottle{},
+		}
+	}
+}
+
+// MinThrottleRate is the lowest limit of WithAdaptiveThrottle, one attempt every 100 seconds.
+const MinThrottleRate = 0.01
+
+// RateLimit returns the number of attempts per second the client currently sends to host with
+// WithAdaptiveThrottle, or 0 if the client has no throttle.
+func (c *BackoffClient) RateLimit(host string) float64 {
+	if c.throttle == nil {
+		return 0
+	}
+
+	c.throttle.mu.Lock()
+	defer c.throttle.mu.Unlock()
+	return c.throttle.host(host).rate
+}
+
+type adaptiveThrottle struct {
+	minRate float64
+	maxRate float64
+	now     func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*hostThrottle
+}
+
+// hostThrottle is the state of the throttle of a single host.
+type hostThrottle struct {
+	rate float64
+	// next is when the next attempt is due.
+	next time.Time
+	// loweredAt is when rate last went down.
+	loweredAt time.Time
+}
+
+// host returns the state of host, which the caller has to hold the lock for.
+func (t *adaptiveThrottle) host(host string) *hostThrottle {
+	state := t.hosts[host]
+	if state == nil {
+		state = &hostThrottle{rate: t.maxRate}
+		t.hosts[host] = state
+	}
+	return state
+}
+
+// reserve takes the next turn to send an attempt to host, and returns how long to wait for it.
+func (t *adaptiveThrottle) reserve(host string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	state := t.host(host)
+	turn := now
+	if state.next.After(now) {
+		turn = state.next
+	}
+	state.next = turn.Add(time.Duration(float64(time.Second) / state.rate))
+	return turn.Sub(now)
+}
+
+// observe adjusts the limit of host to a response to an attempt sent at sentAt.
+func (t *adaptiveThrottle) observe(host string, sentAt time.Time, throttled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.host(host)
+	switch {
+	case !throttled:
+		state.rate += t.minRate
+		if state.rate > t.maxRate {
+			state.rate = t.maxRate
+		}
+	case !sentAt.Before(state.loweredAt):
+		state.rate /= 2
+		if state.rate < t.minRate {
+			state.rate = t.minRate
+		}
+		state.loweredAt = t.now()
+	}
+}
+
+// throttledTry makes an attempt once the throttle of the host lets it, and tells the throttle about its response.
+func (c *call) throttledTry() error {
+	throttle := c.client.throttle
+	if throttle == nil {
+		return c.guardedTry()
+	}
+
+	host := c.host()
+	if err := c.waitFor(throttle.reserve(host)); err != nil {
+		return backoff.Permanent(err)
+	}
+
+	sentAt := throttle.now()
+	err := c.guardedTry()
+	if c.sent && c.resp != nil {
+		throttle.observe(host, sentAt, c.resp.StatusCode == http.StatusTooManyRequests)
+	}
+	return err
+}
+
+// waitFor waits for d, or until the context of the request is done, whose error it returns then.
+func (c *call) waitFor(d time.Duration) error {
+	ctx := c.req.Context()
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	if c.client.sleep != nil {
+		c.client.sleep(d)
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+package httpeeve
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveThrottleSlowsDownOnTooManyRequests(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	now := pinnedNow
+	var waits []time.Duration
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn429Or5XX, WithAdaptiveThrottle(1, 8),
+		WithNow(func() time.Time { return now }),
+		WithSleepFunc(func(d time.Duration) {
+			if d > 0 {
+				waits = append(waits, d)
+			}
+			now = now.Add(d)
+		}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 3, Attempts(resp))
+	assert.Equal(t, []time.Duration{125 * time.Millisecond, 250 * time.Millisecond}, waits)
+	assert.Equal(t, float64(3), client.RateLimit(req.URL.Host), "halved twice, then raised by the minimum rate")
+	assert.Equal(t, float64(8), client.RateLimit("other.example"))
+}
+
+func TestAdaptiveThrottleCountsBurstsOnce(t *testing.T) {
+	now := pinnedNow
+	throttle := &adaptiveThrottle{minRate: 1, maxRate: 16, now: func() time.Time { return now }, hosts: map[string]*hostThrottle{}}
+
+	sentAt := now
+	now = now.Add(time.Second)
+	throttle.observe("api.example", sentAt, true)
+	throttle.observe("api.example", sentAt, true)
+	assert.Equal(t, float64(8), throttle.hosts["api.example"].rate)
+
+	throttle.observe("api.example", now, true)
+	assert.Equal(t, float64(4), throttle.hosts["api.example"].rate)
+
+	for i := 0; i < 3; i++ {
+		throttle.observe("api.example", now, true)
+	}
+	assert.Equal(t, float64(1), throttle.hosts["api.example"].rate, "the limit does not go below the minimum rate")
+}
+
+func TestAdaptiveThrottleKeepsAPositiveMinimumRate(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn429Or5XX, WithAdaptiveThrottle(0, 10),
+		WithNow(func() time.Time { return pinnedNow }))
+	throttle := client.throttle
+	assert.Equal(t, 0.1, throttle.minRate, "a hundredth of the maximum rate")
+
+	for i := 0; i < 100; i++ {
+		throttle.observe("api.example", throttle.now(), true)
+	}
+	assert.Equal(t, 0.1, client.RateLimit("api.example"))
+	assert.Equal(t, 10*time.Second, throttle.reserve("api.example")+throttle.reserve("api.example"))
+
+	throttle.observe("api.example", throttle.now(), false)
+	assert.InDelta(t, 0.2, client.RateLimit("api.example"), 1e-9, "the limit recovers")
+
+	client = NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn429Or5XX, WithAdaptiveThrottle(-1, 0))
+	assert.Equal(t, MinThrottleRate, client.throttle.minRate)
+	assert.Equal(t, MinThrottleRate, client.throttle.maxRate)
+}
+
+func TestAdaptiveThrottleGivesUpWhenTheContextIsDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn429Or5XX, WithAdaptiveThrottle(0.001, 0.001))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.Do(req.WithContext(ctx))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestRateLimitWithoutThrottle(t *testing.T) {
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn429Or5XX)
+	assert.Equal(t, float64(0), client.RateLimit("api.example"))
+}
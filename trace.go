@@ -0,0 +1,74 @@
+package httpeeve
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithChromeTrace writes the timing of every attempt to w as trace events in the JSON array format of Chrome
+// tracing, for deep latency analysis in chrome://tracing. Every request gets its own row. The events of a
+// request are written once it is done. The array is left open, which the viewer accepts.
+func WithChromeTrace(w io.Writer) Option {
+	return func(c *BackoffClient) {
+		c.tracer = &chromeTracer{w: w}
+	}
+}
+
+// chromeTraceEvent is a complete event, see the Trace Event Format.
+type chromeTraceEvent struct {
+	Name      string                 `json:"name"`
+	Category  string                 `json:"cat"`
+	Phase     string                 `json:"ph"`
+	Timestamp int64                  `json:"ts"`
+	Duration  int64                  `json:"dur"`
+	PID       int                    `json:"pid"`
+	TID       int64                  `json:"tid"`
+	Args      map[string]interface{} `json:"args"`
+}
+
+type chromeTracer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	requests int64
+	started  bool
+}
+
+func (t *chromeTracer) trace(req *http.Request, log []AttemptLogEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.requests++
+	for _, entry := range log {
+		if entry.Start.IsZero() {
+			continue
+		}
+
+		event, err := json.Marshal(chromeTraceEvent{
+			Name:      req.Method + " " + req.URL.String(),
+			Category:  "httpeeve",
+			Phase:     "X",
+			Timestamp: entry.Start.UnixNano() / int64(time.Microsecond),
+			Duration:  int64(entry.Duration / time.Microsecond),
+			PID:       1,
+			TID:       t.requests,
+			Args: map[string]interface{}{
+				"attempt": entry.Attempt,
+				"trigger": entry.Trigger,
+				"wait":    entry.Wait.String(),
+			},
+		})
+		if err != nil {
+			continue
+		}
+
+		separator := ",\n"
+		if !t.started {
+			separator, t.started = "[\n", true
+		}
+		io.WriteString(t.w, separator)
+		t.w.Write(event)
+	}
+}
@@ -0,0 +1,50 @@
+package httpeeve
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChromeTrace(t *testing.T) {
+	var requestCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer ts.Close()
+
+	var trace bytes.Buffer
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX, WithChromeTrace(&trace))
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+		_, err := client.Do(req)
+		assert.NoError(t, err)
+	}
+
+	var events []chromeTraceEvent
+	if !assert.NoError(t, json.Unmarshal(append(trace.Bytes(), ']'), &events)) {
+		return
+	}
+
+	if assert.Len(t, events, 4) {
+		for i, event := range events {
+			assert.Equal(t, "X", event.Phase)
+			assert.Equal(t, "GET "+ts.URL, event.Name)
+			assert.True(t, event.Timestamp > 0)
+			if i > 0 {
+				assert.True(t, event.Timestamp >= events[i-1].Timestamp)
+			}
+		}
+		assert.Equal(t, []int64{1, 1, 1, 2}, []int64{events[0].TID, events[1].TID, events[2].TID, events[3].TID})
+		assert.Equal(t, "retry:502", events[1].Args["trigger"])
+	}
+}
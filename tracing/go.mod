@@ -0,0 +1,14 @@
+module github.com/motain/httpeeve/tracing
+
+go 1.15
+
+require (
+	github.com/cenkalti/backoff v2.2.1+incompatible
+	github.com/motain/httpeeve v0.0.0
+	github.com/stretchr/testify v1.7.0
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/sdk v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
+)
+
+replace github.com/motain/httpeeve => ../
@@ -0,0 +1,153 @@
+// Package tracing records the requests sent through httpeeve clients as OpenTelemetry spans, so that traces show
+// every retry instead of one mysteriously slow HTTP call. It is a module of its own, so that httpeeve itself does
+// not depend on OpenTelemetry.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/motain/httpeeve"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/motain/httpeeve/tracing"
+
+// Attributes set on the spans of attempts.
+const (
+	AttemptKey       = attribute.Key("httpeeve.attempt")
+	StatusCodeKey    = attribute.Key("http.status_code")
+	ErrorCategoryKey = attribute.Key("httpeeve.error_category")
+	DelayKey         = attribute.Key("httpeeve.retry_delay_ms")
+)
+
+// WithTracerProvider makes a client record a span for every request, parented by the span in the context of the
+// request if there is one, with a child span for each of its attempts. Attempt spans carry the attempt number,
+// the status code of the response, the category of the error, which is "status", "timeout" or "transport"
+// for failed attempts, and the delay before the next attempt. Clients without this option trace nothing.
+func WithTracerProvider(tp trace.TracerProvider) httpeeve.Option {
+	t := &tracer{
+		tracer:   tp.Tracer(instrumentationName),
+		requests: map[*http.Request]*requestSpans{},
+	}
+
+	return httpeeve.WithHooks(httpeeve.Hooks{
+		OnAttemptStart:   t.attemptStart,
+		OnAttemptDone:    t.attemptDone,
+		OnRetryScheduled: t.retryScheduled,
+		OnGiveUp:         t.giveUp,
+	})
+}
+
+type tracer struct {
+	tracer trace.Tracer
+
+	mu sync.Mutex
+	// requests are keyed by the request passed to Do, as every attempt sends a copy of it
+	requests map[*http.Request]*requestSpans
+}
+
+// requestSpans are the spans of a request in flight: the one of the request and the one of its latest attempt.
+type requestSpans struct {
+	ctx     context.Context
+	request trace.Span
+	attempt trace.Span
+}
+
+func (t *tracer) attemptStart(attemptReq *http.Request, attempt int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	req := httpeeve.OriginalRequest(attemptReq)
+	spans := t.requests[req]
+	if spans == nil {
+		ctx, span := t.tracer.Start(req.Context(), "HTTP "+method(req), trace.WithSpanKind(trace.SpanKindClient))
+		spans = &requestSpans{ctx: ctx, request: span}
+		t.requests[req] = spans
+	}
+
+	_, spans.attempt = t.tracer.Start(spans.ctx, "HTTP "+method(req)+" attempt",
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(AttemptKey.Int(attempt)))
+}
+
+func (t *tracer) attemptDone(attemptReq *http.Request, attempt int, resp *http.Response, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	req := httpeeve.OriginalRequest(attemptReq)
+	spans := t.requests[req]
+	if spans == nil || spans.attempt == nil {
+		return
+	}
+
+	if resp != nil {
+		spans.attempt.SetAttributes(StatusCodeKey.Int(resp.StatusCode))
+	}
+	if err != nil {
+		spans.attempt.SetAttributes(ErrorCategoryKey.String(errorCategory(resp, err)))
+		spans.attempt.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	spans.attempt.End()
+	spans.request.End()
+	delete(t.requests, req)
+}
+
+func (t *tracer) retryScheduled(req *http.Request, attempt int, delay time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := t.requests[req]
+	if spans == nil || spans.attempt == nil {
+		return
+	}
+
+	spans.attempt.SetAttributes(DelayKey.Int64(delay.Milliseconds()))
+	spans.attempt.End()
+	spans.attempt = nil
+}
+
+func (t *tracer) giveUp(req *http.Request, attempts int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := t.requests[req]
+	if spans == nil {
+		return
+	}
+
+	if spans.attempt != nil {
+		spans.attempt.End()
+	}
+	spans.request.RecordError(err)
+	spans.request.SetStatus(codes.Error, err.Error())
+	spans.request.End()
+	delete(t.requests, req)
+}
+
+// errorCategory tells what went wrong with an attempt.
+func errorCategory(resp *http.Response, err error) string {
+	if resp != nil {
+		return "status"
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	return "transport"
+}
+
+func method(req *http.Request) string {
+	if req.Method == "" {
+		return http.MethodGet
+	}
+	return req.Method
+}
@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/motain/httpeeve"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTracerProvider(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requestCount++
+		if requestCount == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client := httpeeve.NewDefaultBackoffClient(http.Client{},
+		httpeeve.WithBackoff(backoff.NewConstantBackOff(time.Millisecond)), WithTracerProvider(provider))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 3) {
+		return
+	}
+	first, second, request := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "HTTP GET", request.Name())
+	assert.Equal(t, codes.Unset, request.Status().Code)
+	for _, attempt := range []sdktrace.ReadOnlySpan{first, second} {
+		assert.Equal(t, "HTTP GET attempt", attempt.Name())
+		assert.Equal(t, request.SpanContext().SpanID(), attempt.Parent().SpanID())
+	}
+
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		AttemptKey.Int(1), StatusCodeKey.Int(503), ErrorCategoryKey.String("status"), DelayKey.Int64(1),
+	}, first.Attributes())
+	assert.Equal(t, codes.Error, first.Status().Code)
+	assert.ElementsMatch(t, []attribute.KeyValue{AttemptKey.Int(2), StatusCodeKey.Int(200)}, second.Attributes())
+}
+
+func TestWithTracerProviderOnGiveUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client := httpeeve.NewDefaultBackoffClient(http.Client{},
+		httpeeve.WithBackoff(&backoff.StopBackOff{}), WithTracerProvider(provider))
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	_, err := client.Do(req)
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+	assert.Contains(t, spans[0].Attributes(), ErrorCategoryKey.String("transport"))
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Len(t, spans[1].Events(), 1, "the error is recorded")
+}
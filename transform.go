@@ -0,0 +1,37 @@
+package httpeeve
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+)
+
+// BodyTransform returns the body to send with the given attempt, starting at 1, based on the original body of
+// the request. It must not modify original.
+type BodyTransform func(attempt int, original []byte) ([]byte, error)
+
+// WithBodyTransform rewrites the request body before each attempt, for signed or nonce-bearing bodies that must
+// change between attempts to remain valid. The original body is buffered, even if it could be replayed
+// otherwise, and requests fail without retrying if transform returns an error.
+func WithBodyTransform(transform BodyTransform) Option {
+	return func(c *BackoffClient) {
+		c.bodyTransform = transform
+	}
+}
+
+// attemptBody returns how to create bodies for the current attempt and their length, or -1 if the length of
+// the request applies.
+func (c *call) attemptBody() (func() io.ReadCloser, int64, error) {
+	if c.client.bodyTransform == nil || c.req.Body == nil {
+		return c.getBody, -1, nil
+	}
+
+	transformed, err := c.client.bodyTransform(c.attempts, c.body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return func() io.ReadCloser {
+		return ioutil.NopCloser(bytes.NewReader(transformed))
+	}, int64(len(transformed)), nil
+}
@@ -0,0 +1,64 @@
+package httpeeve
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyTransform(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX,
+		WithBodyTransform(func(attempt int, original []byte) ([]byte, error) {
+			return []byte(fmt.Sprintf(`%s,"nonce":%d}`, bytes.TrimSuffix(original, []byte("}")), attempt)), nil
+		}), WithIdempotentOnly(false))
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, bytes.NewReader([]byte(`{"amount":10}`)))
+	_, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`{"amount":10,"nonce":1}`,
+		`{"amount":10,"nonce":2}`,
+		`{"amount":10,"nonce":3}`,
+	}, bodies)
+}
+
+func TestBodyTransformErrorIsPermanent(t *testing.T) {
+	var requestCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	client := NewBackoffClient(http.Client{}, &backoff.ZeroBackOff{}, retryOn5XX,
+		WithBodyTransform(func(attempt int, original []byte) ([]byte, error) {
+			if attempt > 1 {
+				return nil, errors.New("out of nonces")
+			}
+			return original, nil
+		}), WithIdempotentOnly(false))
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, bytes.NewReader([]byte("{}")))
+	_, err := client.Do(req)
+
+	assert.EqualError(t, err, "out of nonces")
+	assert.Equal(t, 1, requestCount)
+}
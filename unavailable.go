@@ -0,0 +1,38 @@
+package httpeeve
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// The interval of the backoff is multiplied by a random factor in this range for a 503 without Retry-After.
+const (
+	minUnavailableFactor = 2
+	maxUnavailableFactor = 6
+)
+
+// SpreadServiceUnavailable wraps conditioner to avoid a thundering herd when a service comes back: whenever
+// conditioner retries a 503 Service Unavailable that carries no Retry-After header, the wait is longer and
+// heavily jittered, between two and six times the interval of the backoff.
+func SpreadServiceUnavailable(conditioner Conditioner) Conditioner {
+	return func(resp *http.Response) (bool, error) {
+		shouldRetry, err := conditioner(resp)
+		if err == nil || !shouldRetry || resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "" {
+			return shouldRetry, err
+		}
+
+		return true, &delaySuggestion{err: err, adjust: func(next time.Duration, _ time.Time) time.Duration {
+			factor := minUnavailableFactor + rand.Float64()*(maxUnavailableFactor-minUnavailableFactor)
+			return time.Duration(float64(next) * factor)
+		}}
+	}
+}
+
+// NewDefaultBackoffClient503 is like NewDefaultBackoffClient5XX, but it is gentler on services that are
+// unavailable: it honors Retry-After headers, and spreads retries of 503s without one with SpreadServiceUnavailable.
+func NewDefaultBackoffClient503(httpClient http.Client) *BackoffClient {
+	return NewBackoffClient(httpClient, backoff.NewExponentialBackOff(), HonorRetryAfter(SpreadServiceUnavailable(retryOn5XX)))
+}
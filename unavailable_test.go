@@ -0,0 +1,68 @@
+package httpeeve
+
+import (
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleWaits(conditioner Conditioner, resp *http.Response, n int) []time.Duration {
+	waits := make([]time.Duration, n)
+	for i := range waits {
+		b := &suggestingBackOff{BackOff: backoff.NewConstantBackOff(100 * time.Millisecond), now: time.Now}
+		_, err := conditioner(resp)
+		b.suggest(err)
+		waits[i] = b.NextBackOff()
+	}
+	return waits
+}
+
+func meanAndStddev(waits []time.Duration) (float64, float64) {
+	var sum float64
+	for _, wait := range waits {
+		sum += float64(wait)
+	}
+	mean := sum / float64(len(waits))
+
+	var squares float64
+	for _, wait := range waits {
+		squares += (float64(wait) - mean) * (float64(wait) - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(waits)))
+}
+
+func TestSpreadServiceUnavailable(t *testing.T) {
+	conditioner := SpreadServiceUnavailable(retryOn5XX)
+
+	unavailable := sampleWaits(conditioner, &http.Response{StatusCode: 503, Header: http.Header{}}, 500)
+	internal := sampleWaits(conditioner, &http.Response{StatusCode: 500, Header: http.Header{}}, 500)
+
+	unavailableMean, unavailableStddev := meanAndStddev(unavailable)
+	internalMean, internalStddev := meanAndStddev(internal)
+
+	assert.Equal(t, float64(100*time.Millisecond), internalMean)
+	assert.Equal(t, float64(0), internalStddev)
+
+	assert.InDelta(t, float64(400*time.Millisecond), unavailableMean, float64(30*time.Millisecond))
+	assert.True(t, unavailableStddev > float64(80*time.Millisecond), "503 waits are well spread")
+	for _, wait := range unavailable {
+		assert.True(t, wait >= 200*time.Millisecond && wait <= 600*time.Millisecond, wait.String())
+	}
+}
+
+func TestSpreadServiceUnavailableLeavesRetryAfterAlone(t *testing.T) {
+	resp := &http.Response{StatusCode: 503, Header: http.Header{"Retry-After": {"1"}}}
+	waits := sampleWaits(SpreadServiceUnavailable(retryOn5XX), resp, 10)
+	for _, wait := range waits {
+		assert.Equal(t, 100*time.Millisecond, wait)
+	}
+
+	waits = sampleWaits(HonorRetryAfter(SpreadServiceUnavailable(retryOn5XX)), resp, 10)
+	for _, wait := range waits {
+		assert.Equal(t, time.Second, wait)
+	}
+}